@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mattn/go-isatty"
+)
+
+// colorEnabled decides whether to colorize from --color and the environment.
+// An explicit always or never wins; auto follows NO_COLOR, FORCE_COLOR,
+// CLICOLOR_FORCE and CLICOLOR, then colors only terminals.
+func colorEnabled(mode string, getenv func(string) string, terminal bool) (bool, error) {
+	switch mode {
+	case "always", "true":
+		return true, nil
+	case "never", "false":
+		return false, nil
+	case "auto":
+	default:
+		return false, fmt.Errorf("invalid --color %q: want auto, always or never", mode)
+	}
+
+	// https://no-color.org: any non-empty value disables colors
+	if getenv("NO_COLOR") != "" {
+		return false, nil
+	}
+	if force := getenv("FORCE_COLOR"); force != "" && force != "0" && force != "false" {
+		return true, nil
+	}
+	if force := getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return true, nil
+	}
+	if getenv("CLICOLOR") == "0" || getenv("TERM") == "dumb" {
+		return false, nil
+	}
+	return terminal, nil
+}
+
+// checkColorArgs rejects a color mode given as an argument of its own, as in
+// -c never. The value of --color is optional, so that -c alone means always:
+// it must follow an equal sign, otherwise it is read as a file name.
+func checkColorArgs(mode string, changed bool, args []string) error {
+	if !changed || mode != "always" {
+		return nil
+	}
+	for _, arg := range args {
+		switch arg {
+		case "auto", "always", "never":
+			return fmt.Errorf("--color takes its value after an equal sign: write --color=%s (or ./%s for a file of that name)", arg, arg)
+		}
+	}
+	return nil
+}
+
+// stdoutIsTerminal reports whether standard output is a terminal
+func stdoutIsTerminal() bool {
+	fd := os.Stdout.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
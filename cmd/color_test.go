@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"logtail/internal/colorizer"
+)
+
+func TestColorEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		env      map[string]string
+		terminal bool
+		want     bool
+	}{
+		{name: "Auto on a terminal", mode: "auto", terminal: true, want: true},
+		{name: "Auto redirected", mode: "auto", want: false},
+		{name: "Always redirected", mode: "always", want: true},
+		{name: "Never on a terminal", mode: "never", terminal: true, want: false},
+		{name: "NO_COLOR", mode: "auto", env: map[string]string{"NO_COLOR": "1"}, terminal: true, want: false},
+		{name: "Empty NO_COLOR is ignored", mode: "auto", env: map[string]string{"NO_COLOR": ""}, terminal: true, want: true},
+		{name: "Always beats NO_COLOR", mode: "always", env: map[string]string{"NO_COLOR": "1"}, want: true},
+		{name: "FORCE_COLOR redirected", mode: "auto", env: map[string]string{"FORCE_COLOR": "1"}, want: true},
+		{name: "FORCE_COLOR=0", mode: "auto", env: map[string]string{"FORCE_COLOR": "0"}, terminal: true, want: true},
+		{name: "NO_COLOR beats FORCE_COLOR", mode: "auto", env: map[string]string{"NO_COLOR": "1", "FORCE_COLOR": "1"}, want: false},
+		{name: "CLICOLOR_FORCE redirected", mode: "auto", env: map[string]string{"CLICOLOR_FORCE": "1"}, want: true},
+		{name: "CLICOLOR=0", mode: "auto", env: map[string]string{"CLICOLOR": "0"}, terminal: true, want: false},
+		{name: "Dumb terminal", mode: "auto", env: map[string]string{"TERM": "dumb"}, terminal: true, want: false},
+		{name: "Former boolean flag", mode: "false", terminal: true, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := colorEnabled(tt.mode, func(key string) string { return tt.env[key] }, tt.terminal)
+			if err != nil {
+				t.Fatalf("colorEnabled() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("colorEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := colorEnabled("sometimes", func(string) string { return "" }, true); err == nil {
+		t.Error("colorEnabled() expected error for an invalid mode")
+	}
+}
+
+func TestRunLogTailColorModes(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "app.log")
+	os.WriteFile(testFile, []byte("2024-09-30 10:30:45 ERROR Database connection failed\n"), 0644)
+
+	filterPattern = ""
+	followMode = false
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+	t.Setenv("CLICOLOR_FORCE", "")
+	defer func() {
+		colorMode = "auto"
+		colorOutput = false
+		colorizer.SetEnabled(false)
+	}()
+
+	for mode, wantEscapes := range map[string]bool{"auto": false, "always": true, "never": false} {
+		colorMode = mode
+		output := captureStdout(t, func() {
+			if err := runLogTail(nil, []string{testFile}); err != nil {
+				t.Errorf("runLogTail() unexpected error: %v", err)
+			}
+		})
+
+		// Output goes to a pipe, so auto means no colors
+		if hasEscapes := strings.Contains(output, "\x1b["); hasEscapes != wantEscapes {
+			t.Errorf("--color=%s: output %q, want escapes %v", mode, output, wantEscapes)
+		}
+	}
+}
+
+func TestColorModeAsSeparateArgument(t *testing.T) {
+	defer func() {
+		colorMode = "auto"
+		rootCmd.SetArgs(nil)
+	}()
+
+	for _, args := range [][]string{{"-c", "never", "app.log"}, {"--color", "auto", "app.log"}} {
+		colorMode = "auto"
+		rootCmd.SetArgs(args)
+		err := rootCmd.Execute()
+		if err == nil || !strings.Contains(err.Error(), "--color="+args[1]) {
+			t.Errorf("%v: error = %v, want a hint to write --color=%s", args, err, args[1])
+		}
+	}
+
+	for _, tt := range []struct {
+		mode    string
+		changed bool
+		args    []string
+		wantErr bool
+	}{
+		{mode: "always", changed: true, args: []string{"app.log"}},
+		{mode: "never", changed: true, args: []string{"never"}},
+		{mode: "auto", changed: false, args: []string{"always"}},
+		{mode: "always", changed: true, args: []string{"app.log", "always"}, wantErr: true},
+	} {
+		if err := checkColorArgs(tt.mode, tt.changed, tt.args); (err != nil) != tt.wantErr {
+			t.Errorf("checkColorArgs(%q, %v, %v) = %v, wantErr %v", tt.mode, tt.changed, tt.args, err, tt.wantErr)
+		}
+	}
+}
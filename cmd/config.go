@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"logtail/internal/colorizer"
+	"logtail/internal/config"
+	"logtail/internal/parser"
+)
+
+// loadConfig reads the file given by --config, or the default one if it exists
+func loadConfig() (*config.Config, error) {
+	path := configPath
+	if path == "" {
+		path = config.DefaultPath()
+		if path == "" {
+			return &config.Config{}, nil
+		}
+	}
+
+	cfg, err := config.Load(path)
+	if errors.Is(err, os.ErrNotExist) && configPath == "" {
+		return &config.Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot load config: %v", err)
+	}
+	return cfg, nil
+}
+
+// applyTheme sets the theme selected by --theme or the config file, for the
+// color depth of the terminal
+func applyTheme(cfg *config.Config) error {
+	name := themeName
+	if name == "" {
+		name = cfg.Theme
+	}
+	if name == "" {
+		name = colorizer.DefaultTheme
+	}
+
+	theme, err := resolveTheme(name, cfg.Themes, make(map[string]bool))
+	if err != nil {
+		return err
+	}
+	if err := colorizer.SetTheme(theme, colorizer.DetectDepth(os.Getenv)); err != nil {
+		return fmt.Errorf("invalid theme %s: %v", name, err)
+	}
+	return nil
+}
+
+// resolveTheme returns the named theme, user themes taking precedence over
+// built-in ones. A user theme may extend a built-in theme of the same name.
+func resolveTheme(name string, user map[string]config.Theme, seen map[string]bool) (colorizer.Theme, error) {
+	if theme, ok := user[name]; ok && !seen[name] {
+		seen[name] = true
+
+		base := theme.Extends
+		if base == "" {
+			base = colorizer.DefaultTheme
+		}
+		parent, err := resolveTheme(base, user, seen)
+		if err != nil {
+			return nil, err
+		}
+		return parent.Extend(theme.Styles), nil
+	}
+
+	if theme, ok := colorizer.Themes[name]; ok {
+		return theme, nil
+	}
+	if seen[name] {
+		return nil, fmt.Errorf("theme %s extends itself", name)
+	}
+	return nil, fmt.Errorf("unknown theme %s (built-in themes: %s)", name, strings.Join(colorizer.ThemeNames(), ", "))
+}
+
+// applyHashing selects the values colored by hash from --hash-colors,
+// --hash-pattern and the config file. The names source and file stand for the
+// source of entries and the file prefix of multi-file follow mode; other names
+// are keys of key=value pairs.
+func applyHashing(cfg *config.Config) error {
+	var hashing colorizer.Hashing
+	hashFiles = false
+
+	for _, name := range append(cfg.HashColors, hashColors...) {
+		switch name {
+		case "source":
+			hashing.Source = true
+		case "file":
+			hashFiles = true
+		default:
+			hashing.Keys = append(hashing.Keys, name)
+		}
+	}
+
+	for _, expr := range append(cfg.HashPatterns, hashPatterns...) {
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("invalid hash pattern %q: %v", expr, err)
+		}
+		hashing.Patterns = append(hashing.Patterns, pattern)
+	}
+
+	colorizer.SetHashing(hashing)
+	return nil
+}
+
+// applyLevels registers the level names of the config file
+func applyLevels(cfg *config.Config) error {
+	for name, levelName := range cfg.Levels {
+		level, err := parser.ParseLevel(levelName)
+		if err != nil {
+			return fmt.Errorf("config level %q: %v", name, err)
+		}
+		if err := parser.RegisterLevel(name, level); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"logtail/internal/colorizer"
+	"logtail/internal/config"
+	"logtail/internal/parser"
+)
+
+func TestResolveTheme(t *testing.T) {
+	user := map[string]config.Theme{
+		"paper": {Extends: "light", Styles: map[string]string{"url": "underline 26"}},
+		"dark":  {Extends: "dark", Styles: map[string]string{"timestamp": "245"}},
+		"plain": {Styles: map[string]string{"number": ""}},
+		"loop1": {Extends: "loop2"},
+		"loop2": {Extends: "loop1"},
+	}
+
+	tests := []struct {
+		name    string
+		element string
+		want    string
+		wantErr string
+	}{
+		{name: "paper", element: "url", want: "underline 26"},
+		{name: "paper", element: "level.info", want: colorizer.Themes["light"]["level.info"]},
+		{name: "dark", element: "timestamp", want: "245"},
+		{name: "dark", element: "url", want: colorizer.Themes["dark"]["url"]},
+		{name: "plain", element: "url", want: colorizer.Themes[colorizer.DefaultTheme]["url"]},
+		{name: "solarized", element: "source", want: colorizer.Themes["solarized"]["source"]},
+		{name: "loop1", wantErr: "extends itself"},
+		{name: "missing", wantErr: "unknown theme"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+"/"+tt.element, func(t *testing.T) {
+			theme, err := resolveTheme(tt.name, user, make(map[string]bool))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("resolveTheme() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveTheme() unexpected error: %v", err)
+			}
+			if got := theme[tt.element]; got != tt.want {
+				t.Errorf("resolveTheme() %s = %q, want %q", tt.element, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigExplicitPath(t *testing.T) {
+	configPath = filepath.Join(t.TempDir(), "missing.json")
+	defer func() {
+		configPath = ""
+	}()
+
+	if _, err := loadConfig(); err == nil {
+		t.Error("loadConfig() expected error for a missing --config file")
+	}
+
+	os.WriteFile(configPath, []byte(`{"theme": "no-such-theme"}`), 0644)
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig() unexpected error: %v", err)
+	}
+	if err := applyTheme(cfg); err == nil {
+		t.Error("applyTheme() expected error for an unknown theme")
+	}
+}
+
+func TestApplyHashing(t *testing.T) {
+	defer func() {
+		hashColors, hashPatterns, hashFiles = nil, nil, false
+		colorizer.SetHashing(colorizer.Hashing{})
+	}()
+
+	hashColors = []string{"request_id", "file"}
+	cfg := &config.Config{HashColors: []string{"source"}, HashPatterns: []string{`id:(\w+)`}}
+	if err := applyHashing(cfg); err != nil {
+		t.Fatalf("applyHashing() unexpected error: %v", err)
+	}
+	if !hashFiles {
+		t.Error("applyHashing() did not enable hashing of file prefixes")
+	}
+
+	hashPatterns = []string{"("}
+	if err := applyHashing(cfg); err == nil {
+		t.Error("applyHashing() expected error for an invalid pattern")
+	}
+}
+
+func TestApplyLevels(t *testing.T) {
+	cfg := &config.Config{Levels: map[string]string{"AUDIT": "notice", "45": "error"}}
+	if err := applyLevels(cfg); err != nil {
+		t.Fatalf("applyLevels() unexpected error: %v", err)
+	}
+	if got := parser.ParseLogLine("2024-09-30 10:30:45 AUDIT login").Level; got != parser.LevelNotice {
+		t.Errorf("AUDIT level = %v, want NOTICE", got)
+	}
+	if got := parser.ParseLogLine(`{"level":45,"msg":"x"}`).Level; got != parser.LevelError {
+		t.Errorf("level 45 = %v, want ERROR", got)
+	}
+
+	for _, levels := range []map[string]string{{"AUDIT": "loud"}, {"two words": "info"}} {
+		if err := applyLevels(&config.Config{Levels: levels}); err == nil {
+			t.Errorf("applyLevels(%v) expected error", levels)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+
+	"logtail/internal/parser"
+)
+
+// containerFields derives the container and pod of a container runtime log
+// from its path:
+//
+//	/var/lib/docker/containers/<id>/<id>-json.log
+//	/var/log/pods/<namespace>_<pod>_<uid>/<container>/0.log
+//	/var/log/containers/<pod>_<namespace>_<container>-<id>.log
+func containerFields(path string) []parser.Field {
+	dir, name := filepath.Split(filepath.Clean(path))
+	dir = filepath.Clean(dir)
+	parent := filepath.Base(dir)
+
+	switch {
+	case strings.HasPrefix(name, parent+"-json.log") && filepath.Base(filepath.Dir(dir)) == "containers":
+		return []parser.Field{{Key: "container_id", Value: parent}}
+
+	case filepath.Base(filepath.Dir(filepath.Dir(dir))) == "pods":
+		pod := strings.SplitN(filepath.Base(filepath.Dir(dir)), "_", 3)
+		if len(pod) != 3 {
+			return nil
+		}
+		return []parser.Field{
+			{Key: "namespace", Value: pod[0]},
+			{Key: "pod", Value: pod[1]},
+			{Key: "container", Value: parent},
+		}
+
+	case parent == "containers":
+		pod := strings.SplitN(strings.TrimSuffix(name, ".log"), "_", 3)
+		if len(pod) != 3 {
+			return nil
+		}
+		dash := strings.LastIndexByte(pod[2], '-')
+		if dash <= 0 {
+			return nil
+		}
+		return []parser.Field{
+			{Key: "namespace", Value: pod[1]},
+			{Key: "pod", Value: pod[0]},
+			{Key: "container", Value: pod[2][:dash]},
+			{Key: "container_id", Value: pod[2][dash+1:]},
+		}
+	}
+	return nil
+}
+
+// join parses the line of a container log completed by p with the partial
+// records before it. The line passes the filter when any of its records or
+// the whole line does.
+func (s *logStream) join(p parsedLine) parsedLine {
+	matched := s.fragmentMatched || p.matched
+	entry := s.parser.ParseRecords(append(s.fragments, p.record))
+	s.fragments, s.fragmentMatched = s.fragments[:0], false
+
+	p.parsed = true
+	p.matched = matched || s.filter == nil || s.filter.MatchString(entry.Raw)
+	return s.display(s.parser, p, entry)
+}
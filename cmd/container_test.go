@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"logtail/internal/colorizer"
+	"logtail/internal/parser"
+)
+
+func TestContainerFields(t *testing.T) {
+	tests := []struct {
+		path string
+		want []parser.Field
+	}{
+		{
+			path: "/var/lib/docker/containers/3f4e1a/3f4e1a-json.log",
+			want: []parser.Field{{Key: "container_id", Value: "3f4e1a"}},
+		},
+		{
+			path: "/var/lib/docker/containers/3f4e1a/3f4e1a-json.log.1",
+			want: []parser.Field{{Key: "container_id", Value: "3f4e1a"}},
+		},
+		{
+			path: "/var/log/pods/shop_api-7d9f_5c1e-42/server/0.log",
+			want: []parser.Field{
+				{Key: "namespace", Value: "shop"},
+				{Key: "pod", Value: "api-7d9f"},
+				{Key: "container", Value: "server"},
+			},
+		},
+		{
+			path: "/var/log/containers/api-7d9f_shop_server-9b2c8e.log",
+			want: []parser.Field{
+				{Key: "namespace", Value: "shop"},
+				{Key: "pod", Value: "api-7d9f"},
+				{Key: "container", Value: "server"},
+				{Key: "container_id", Value: "9b2c8e"},
+			},
+		},
+		{path: "/var/log/syslog"},
+		{path: "app.log"},
+		{path: "-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := containerFields(tt.path); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("containerFields(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContainerLogs(t *testing.T) {
+	originalColor, originalWorkers, originalChunkSize := colorOutput, workers, parallelChunkSize
+	defer func() {
+		colorOutput, workers, parallelChunkSize = originalColor, originalWorkers, originalChunkSize
+	}()
+	colorOutput = false
+	workers = 4
+	parallelChunkSize = 64
+
+	log := `{"log":"2024-09-30 10:30:45 INFO started\n","stream":"stdout","time":"2024-09-30T10:30:45Z"}` + "\n" +
+		`{"log":"2024-09-30 10:30:46 ERROR a line split ","stream":"stderr","time":"2024-09-30T10:30:46Z"}` + "\n" +
+		`{"log":"by the runtime\n","stream":"stderr","time":"2024-09-30T10:30:46Z"}` + "\n" +
+		"2024-09-30T10:30:47Z stdout P WARN split in \n" +
+		"2024-09-30T10:30:47Z stdout P three \n" +
+		"2024-09-30T10:30:47Z stdout F records\n"
+	want := "2024-09-30 10:30:45 INFO started\n" +
+		"2024-09-30 10:30:46 ERROR a line split by the runtime\n" +
+		"WARN split in three records\n"
+
+	for _, chunked := range []bool{false, true} {
+		if got := runStream(t, log, chunked); got != want {
+			t.Errorf("chunked=%v: output = %q, want %q", chunked, got, want)
+		}
+	}
+}
+
+func TestContainerLogsFilterAndMetadata(t *testing.T) {
+	originalLayout, originalColor := layout, colorOutput
+	defer func() {
+		layout, colorOutput = originalLayout, originalColor
+	}()
+	colorOutput = false
+	layout = colorizer.NewLayout(nil, nil, nil)
+
+	stream := newLogStream("/var/log/pods/shop_api_5c1e/server/0.log", "", regexp.MustCompile("timeout"))
+	lines := []string{
+		`2024-09-30T10:30:45Z stdout F {"level":"info","msg":"served"}`,
+		`2024-09-30T10:30:46Z stderr P {"level":"error","msg":"upstream timeout`,
+		`2024-09-30T10:30:46Z stderr F  after 30s"}`,
+	}
+
+	var output []string
+	for _, line := range lines {
+		if formatted, ok := stream.format(line); ok {
+			output = append(output, formatted)
+		}
+	}
+
+	want := "10:30:46.000 ERROR upstream timeout after 30s" + strings.Repeat(" ", 14) +
+		" stream=stderr namespace=shop pod=api container=server"
+	if len(output) != 1 || output[0] != want {
+		t.Errorf("output = %q, want [%q]", output, want)
+	}
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"logtail/internal/colorizer"
+	"logtail/internal/parser"
+)
+
+// Modes of --expand-json
+const (
+	expandPretty  = "pretty"
+	expandCompact = "compact"
+)
+
+// ansiEscape matches the color codes a prefix may hold
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// checkExpandMode validates --expand-json
+func checkExpandMode(mode string) error {
+	switch mode {
+	case "", expandPretty, expandCompact:
+		return nil
+	}
+	return fmt.Errorf("invalid --expand-json mode %q: use pretty or compact", mode)
+}
+
+// expandJSON formats line, whose entry is given, with its embedded JSON
+// reformatted according to --expand-json. In compact mode, the JSON is
+// collapsed in place. In pretty mode, it is removed from the line and
+// printed indented below it, colored like the line. ok is false when the
+// line holds no JSON.
+func (s *logStream) expandJSON(lineParser *parser.Parser, entry parser.LogEntry, line string) (string, bool) {
+	spans := parser.EmbeddedJSON(line)
+	if len(spans) == 0 {
+		return "", false
+	}
+
+	var head strings.Builder
+	var blocks []string
+	last := 0
+	for _, span := range spans {
+		head.WriteString(line[last:span.Start])
+		last = span.End
+
+		var buf bytes.Buffer
+		if expandMode == expandCompact {
+			json.Compact(&buf, []byte(line[span.Start:span.End]))
+			head.Write(buf.Bytes())
+			continue
+		}
+		json.Indent(&buf, []byte(line[span.Start:span.End]), "", "  ")
+		blocks = append(blocks, buf.String())
+	}
+	head.WriteString(line[last:])
+
+	text := head.String()
+	if len(blocks) > 0 {
+		text = strings.TrimRight(text, " \t")
+	}
+	headEntry := lineParser.Parse(text)
+	if headEntry.Level == parser.LevelUnknown {
+		// The level may have been inside the JSON
+		headEntry.Level = entry.Level
+	}
+
+	output := text
+	if colorOutput {
+		output = colorizer.ColorizeLogLine(headEntry, text)
+	}
+	if len(blocks) == 0 {
+		return output, true
+	}
+
+	// Blocks are indented past the prefix and line number of the line
+	width := utf8.RuneCountInString(ansiEscape.ReplaceAllString(s.prefix, ""))
+	if showLineNum {
+		width += 8
+	}
+	indent := strings.Repeat(" ", width+4)
+
+	var b strings.Builder
+	b.WriteString(output)
+	for _, block := range blocks {
+		if colorOutput {
+			block = colorizer.ColorizeJSON(block, headEntry.Level)
+		}
+		for _, blockLine := range strings.Split(block, "\n") {
+			b.WriteString("\n")
+			b.WriteString(indent)
+			b.WriteString(blockLine)
+		}
+	}
+	return b.String(), true
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestExpandJSON(t *testing.T) {
+	originalMode, originalColor, originalLineNum := expandMode, colorOutput, showLineNum
+	defer func() {
+		expandMode, colorOutput, showLineNum = originalMode, originalColor, originalLineNum
+	}()
+	colorOutput = false
+
+	line := `2024-09-30 10:00:00 ERROR request failed body={"code": 500, "errors": ["timeout"]}`
+	tests := []struct {
+		name     string
+		mode     string
+		lineNum  bool
+		log      string
+		expected string
+	}{
+		{
+			name:     "Off",
+			log:      line + "\n",
+			expected: line + "\n",
+		},
+		{
+			name:     "Compact",
+			mode:     expandCompact,
+			log:      line + "\n",
+			expected: `2024-09-30 10:00:00 ERROR request failed body={"code":500,"errors":["timeout"]}` + "\n",
+		},
+		{
+			name: "Pretty",
+			mode: expandPretty,
+			log:  line + "\n",
+			expected: "2024-09-30 10:00:00 ERROR request failed body=\n" +
+				"    {\n" +
+				"      \"code\": 500,\n" +
+				"      \"errors\": [\n" +
+				"        \"timeout\"\n" +
+				"      ]\n" +
+				"    }\n",
+		},
+		{
+			name:    "Pretty with line numbers",
+			mode:    expandPretty,
+			lineNum: true,
+			log:     `INFO done {"ok":true}` + "\n",
+			expected: "     1: INFO done\n" +
+				"            {\n" +
+				"              \"ok\": true\n" +
+				"            }\n",
+		},
+		{
+			name:     "No JSON",
+			mode:     expandPretty,
+			log:      "INFO [main] served [200] in 5ms\n",
+			expected: "INFO [main] served [200] in 5ms\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expandMode, showLineNum = tt.mode, tt.lineNum
+			if got := runStream(t, tt.log, false); got != tt.expected {
+				t.Errorf("output = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCheckExpandMode(t *testing.T) {
+	for _, mode := range []string{"", "pretty", "compact"} {
+		if err := checkExpandMode(mode); err != nil {
+			t.Errorf("checkExpandMode(%q) returned %v", mode, err)
+		}
+	}
+	if err := checkExpandMode("yaml"); err == nil {
+		t.Error("checkExpandMode(\"yaml\") returned no error")
+	}
+}
@@ -0,0 +1,378 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+
+	"logtail/internal/checkpoint"
+	"logtail/internal/input"
+)
+
+const (
+	// followPollInterval is how often an idle file is checked for new content
+	followPollInterval = 100 * time.Millisecond
+
+	// followBatchSize bounds how many lines a follower hands over at once, so
+	// that a burst in one file cannot starve the others
+	followBatchSize = 256
+)
+
+type FollowFile struct {
+	file     *os.File
+	filename string
+	reader   *input.LineReader
+	stream   *logStream
+	position int64
+
+	// lastGrowth is when the file last grew, used to time out partial lines
+	lastGrowth time.Time
+}
+
+func followFiles(ctx context.Context, filenames []string, filter *regexp.Regexp) error {
+	// For follow mode, we need to track file positions and watch for changes
+	files := make([]*FollowFile, 0, len(filenames))
+
+	// Cleanup
+	defer func() {
+		for _, f := range files {
+			f.file.Close()
+		}
+	}()
+
+	// Initialize files
+	for _, filename := range filenames {
+		file, err := os.Open(filename)
+		if err != nil {
+			return fmt.Errorf("cannot open file %s: %v", filename, err)
+		}
+
+		// Compressed files are rotated archives and never grow
+		if compressed, err := input.IsCompressed(file); err != nil || compressed {
+			file.Close()
+			if err != nil {
+				return fmt.Errorf("error reading file %s: %v", filename, err)
+			}
+			return fmt.Errorf("cannot follow compressed file %s", filename)
+		}
+
+		// First, read existing content from the beginning
+		f := &FollowFile{
+			filename: filename,
+			stream:   newLogStream(filename, followPrefix(filenames, filename), filter),
+		}
+		f.restart(file)
+
+		if skipBinary && f.reader.LooksBinary() {
+			fmt.Fprintf(os.Stderr, "logtail: skipping binary file %s\n", filename)
+			file.Close()
+			continue
+		}
+		files = append(files, f)
+
+		if len(filenames) > 1 {
+			fmt.Fprintf(stdout, "==> %s <==\n", filename)
+		}
+
+		// Resume where the previous run stopped, if it was recorded
+		resumed := false
+		if checkpoints != nil {
+			if resumed, err = f.resume(ctx); err != nil {
+				return err
+			}
+		}
+
+		// Replay rotated archives before the live file
+		if rotatedMode && !resumed {
+			if err := processArchives(ctx, f.stream); err != nil {
+				return err
+			}
+		}
+
+		// Process existing content
+		if err := readLines(ctx, f.reader, f.stream); err != nil {
+			return fmt.Errorf("error reading file %s: %v", filename, err)
+		}
+		f.position = f.reader.Offset()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Each file is followed by its own goroutine feeding a bounded channel
+	batches := make([]chan []string, len(files))
+	ready := make(chan struct{}, 1)
+	errs := make(chan error, len(files))
+	var wg sync.WaitGroup
+
+	for i, f := range files {
+		batches[i] = make(chan []string, 1)
+
+		wg.Add(1)
+		go func(f *FollowFile, out chan<- []string) {
+			defer wg.Done()
+			defer func() {
+				// Wake the writer so it notices the closed channel
+				close(out)
+				select {
+				case ready <- struct{}{}:
+				default:
+				}
+			}()
+
+			if err := f.follow(ctx, out, ready); err != nil {
+				errs <- err
+				cancel()
+			}
+		}(f, batches[i])
+	}
+
+	writeBatches(stdout, batches, ready)
+	wg.Wait()
+
+	if checkpoints != nil {
+		for _, f := range files {
+			f.checkpoint()
+		}
+	}
+
+	select {
+	case err := <-errs:
+		return err
+	default:
+		return nil
+	}
+}
+
+// restart makes file the one being followed and reads it from the beginning
+func (f *FollowFile) restart(file *os.File) {
+	f.file = file
+	f.reader = input.NewLineReader(file, maxLineLength)
+	f.position = 0
+	f.lastGrowth = time.Now()
+
+	// A trailing line without newline may still be being written
+	f.reader.SetHoldPartial(true)
+}
+
+// resume continues from the position saved by a previous run. It reports
+// false when the file has no saved position.
+func (f *FollowFile) resume(ctx context.Context) (bool, error) {
+	saved, ok := checkpoints.Get(f.filename)
+	if !ok {
+		return false, nil
+	}
+
+	info, err := f.file.Stat()
+	if err != nil {
+		return false, fmt.Errorf("error getting file info for %s: %v", f.filename, err)
+	}
+
+	plan := planResume(f.filename, info, saved)
+	if !plan.fresh() {
+		f.stream.lineNum = saved.Line
+	}
+
+	if plan.previous != "" {
+		if _, err := processFrom(ctx, plan.previous, plan.previousOffset, f.stream); err != nil {
+			return false, err
+		}
+	}
+
+	if _, err := f.file.Seek(plan.offset, io.SeekStart); err != nil {
+		return false, fmt.Errorf("error seeking in file %s: %v", f.filename, err)
+	}
+	f.restart(f.file)
+	f.reader.SetOffset(plan.offset)
+
+	return true, nil
+}
+
+// checkpoint records the position of the last complete line read
+func (f *FollowFile) checkpoint() {
+	info, err := f.file.Stat()
+	if err != nil {
+		return
+	}
+
+	checkpoints.Set(f.filename, checkpoint.FileState{
+		Inode:  checkpoint.Inode(info),
+		Offset: f.reader.Offset() - int64(f.reader.Pending()),
+		Line:   f.stream.lineNum,
+	})
+}
+
+// replacement returns the file now found at the followed path when it is no
+// longer the one being read, as happens after a rename-and-recreate rotation
+func (f *FollowFile) replacement(current os.FileInfo) *os.File {
+	info, err := os.Stat(f.filename)
+	if err != nil || os.SameFile(info, current) {
+		// Not recreated yet, or not rotated at all
+		return nil
+	}
+
+	file, err := os.Open(f.filename)
+	if err != nil {
+		return nil
+	}
+	return file
+}
+
+// follow polls the file for new lines and hands them over in batches until
+// ctx is cancelled. Each ready notification tells the writer a batch is waiting.
+func (f *FollowFile) follow(ctx context.Context, out chan<- []string, ready chan<- struct{}) error {
+	var batch []string
+
+	// send hands the pending batch to the writer, which keeps draining until
+	// every follower is done, so this never blocks forever
+	send := func() {
+		if len(batch) == 0 {
+			return
+		}
+		out <- batch
+		batch = nil
+
+		select {
+		case ready <- struct{}{}:
+		default:
+		}
+	}
+
+	// flushPartial emits a partial line that will never be completed
+	flushPartial := func() {
+		if line, ok := f.reader.Flush(); ok {
+			if output, ok := f.stream.format(line); ok {
+				batch = append(batch, output)
+			}
+		}
+	}
+
+	// stop hands over what is left once ctx is cancelled
+	stop := func() error {
+		// With a state file, the partial line is left for the next run
+		if checkpoints == nil {
+			flushPartial()
+		}
+		send()
+		return nil
+	}
+
+	for {
+		// A file that keeps growing never waits for the next poll, so check
+		// for cancellation on every pass
+		if ctx.Err() != nil {
+			return stop()
+		}
+
+		// Check if file has grown
+		fileInfo, err := f.file.Stat()
+		if err != nil {
+			return fmt.Errorf("error getting file info for %s: %v", f.filename, err)
+		}
+
+		// A file smaller than what we read was truncated in place (copytruncate)
+		if fileInfo.Size() < f.position {
+			if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+				return fmt.Errorf("error seeking in file %s: %v", f.filename, err)
+			}
+			flushPartial()
+			f.restart(f.file)
+			if stats != nil {
+				stats.rotated(f.filename)
+			}
+		}
+
+		grown := fileInfo.Size() > f.position
+		if grown {
+			f.lastGrowth = time.Now()
+
+			for ctx.Err() == nil {
+				line, err := f.reader.ReadLine()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					return fmt.Errorf("error reading file %s: %v", f.filename, err)
+				}
+
+				if output, ok := f.stream.format(line); ok {
+					batch = append(batch, output)
+				}
+
+				if len(batch) == followBatchSize {
+					send()
+				}
+			}
+			f.position = f.reader.Offset()
+		} else if file := f.replacement(fileInfo); file != nil {
+			// The old file is fully read: switch to the new one at the same path
+			flushPartial()
+			f.file.Close()
+			f.restart(file)
+			if stats != nil {
+				stats.rotated(f.filename)
+			}
+			grown = true
+		} else if f.reader.Pending() > 0 && time.Since(f.lastGrowth) >= partialLineTimeout {
+			// Give up waiting for the end of a partial line once the writer went idle
+			flushPartial()
+		}
+
+		send()
+
+		// If no new content, sleep briefly before checking again
+		if !grown {
+			select {
+			case <-ctx.Done():
+				return stop()
+			case <-time.After(followPollInterval):
+			}
+		}
+	}
+}
+
+// writeBatches is the single output stage of follow mode. It takes at most one
+// batch from each follower in turn, so every file gets a fair share of the
+// output while lines of a given file keep their order. It returns once every
+// follower has closed its channel, after writing whatever they left queued.
+func writeBatches(out *bufio.Writer, batches []chan []string, ready <-chan struct{}) {
+	defer out.Flush()
+	open := len(batches)
+	closed := make([]bool, len(batches))
+
+	for open > 0 {
+		progressed := false
+
+		for i, ch := range batches {
+			if closed[i] {
+				continue
+			}
+
+			select {
+			case batch, ok := <-ch:
+				if !ok {
+					closed[i] = true
+					open--
+					continue
+				}
+				for _, line := range batch {
+					out.WriteString(line)
+					out.WriteByte('\n')
+				}
+				progressed = true
+			default:
+			}
+		}
+
+		if !progressed && open > 0 {
+			// Nothing queued: show what we have and wait for the next batch
+			out.Flush()
+			<-ready
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// captureStdout runs fn and returns everything it wrote to os.Stdout
+func captureStdout(t *testing.T, fn func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Cannot create pipe: %v", err)
+	}
+
+	original, originalBuffer := os.Stdout, stdout
+	os.Stdout, stdout = w, bufio.NewWriter(w)
+	defer func() {
+		os.Stdout, stdout = original, originalBuffer
+	}()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+
+	fn()
+	stdout.Flush()
+	w.Close()
+	return <-output
+}
+
+func TestWriteBatchesFairness(t *testing.T) {
+	busy := make(chan []string, 3)
+	quiet := make(chan []string, 3)
+	ready := make(chan struct{}, 1)
+
+	// A bursting file must not delay the other one until its burst is over
+	busy <- []string{"busy 1", "busy 2"}
+	busy <- []string{"busy 3", "busy 4"}
+	busy <- []string{"busy 5"}
+	quiet <- []string{"quiet 1"}
+	quiet <- []string{"quiet 2"}
+	close(busy)
+	close(quiet)
+
+	var buf bytes.Buffer
+	writeBatches(bufio.NewWriter(&buf), []chan []string{busy, quiet}, ready)
+
+	want := "busy 1\nbusy 2\nquiet 1\nbusy 3\nbusy 4\nquiet 2\nbusy 5\n"
+	if buf.String() != want {
+		t.Errorf("writeBatches() output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestFollowFilesCancellation(t *testing.T) {
+	tempDir := t.TempDir()
+	first := filepath.Join(tempDir, "first.log")
+	second := filepath.Join(tempDir, "second.log")
+
+	for _, name := range []string{first, second} {
+		if err := os.WriteFile(name, []byte("initial\n"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	filterPattern = ""
+	colorOutput = false
+	showLineNum = true
+	defer func() {
+		showLineNum = false
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var followErr error
+
+	output := captureStdout(t, func() {
+		done := make(chan struct{})
+		go func() {
+			followErr = followFiles(ctx, []string{first, second}, nil)
+			close(done)
+		}()
+
+		time.Sleep(2 * followPollInterval)
+		file, err := os.OpenFile(second, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Errorf("Cannot append to test file: %v", err)
+		} else {
+			file.WriteString("appended\n")
+			file.Close()
+		}
+		time.Sleep(3 * followPollInterval)
+
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Error("followFiles() did not stop after cancellation")
+		}
+	})
+
+	if followErr != nil {
+		t.Errorf("followFiles() unexpected error: %v", followErr)
+	}
+
+	expected := "[" + second + "]      2: appended"
+	if !strings.Contains(output, expected) {
+		t.Errorf("Expected output to contain %q, got: %s", expected, output)
+	}
+}
+
+func TestFollowFilesRotation(t *testing.T) {
+	tempDir := t.TempDir()
+	logFile := filepath.Join(tempDir, "app.log")
+
+	if err := os.WriteFile(logFile, []byte("before rotation\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	filterPattern = ""
+	colorOutput = false
+	stats = newSummary()
+	defer func() {
+		stats = nil
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	output := captureStdout(t, func() {
+		done := make(chan struct{})
+		go func() {
+			followFiles(ctx, []string{logFile}, nil)
+			close(done)
+		}()
+		time.Sleep(2 * followPollInterval)
+
+		// Rename-and-recreate rotation
+		if err := os.Rename(logFile, logFile+".1"); err != nil {
+			t.Errorf("Cannot rotate test file: %v", err)
+		}
+		os.WriteFile(logFile, []byte("after rotation\n"), 0644)
+		time.Sleep(3 * followPollInterval)
+
+		// In-place truncation, as done by copytruncate
+		os.WriteFile(logFile, []byte("truncated\n"), 0644)
+		time.Sleep(3 * followPollInterval)
+
+		cancel()
+		<-done
+	})
+
+	for _, expected := range []string{"before rotation", "after rotation", "truncated"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %q, got: %s", expected, output)
+		}
+	}
+
+	if rotations := stats.files[logFile].rotations; rotations != 2 {
+		t.Errorf("Expected 2 rotations to be detected, got %d", rotations)
+	}
+}
+
+// appendContinuously appends lines to name until stop is closed
+func appendContinuously(t *testing.T, name string, stop <-chan struct{}) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		file, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Errorf("Cannot append to test file: %v", err)
+			return
+		}
+		defer file.Close()
+
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				file.WriteString("2024-09-30 10:30:45 INFO busy writer\n")
+			}
+		}
+	}()
+	return done
+}
+
+func TestFollowFilesCancellationWhileGrowing(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "busy.log")
+	if err := os.WriteFile(logFile, []byte("initial\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	filterPattern = ""
+	colorOutput = false
+
+	stopWriter := make(chan struct{})
+	writerDone := appendContinuously(t, logFile, stopWriter)
+	defer func() {
+		close(stopWriter)
+		<-writerDone
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	captureStdout(t, func() {
+		done := make(chan struct{})
+		go func() {
+			followFiles(ctx, []string{logFile}, nil)
+			close(done)
+		}()
+
+		// The file never stops growing, so the follower never waits for a poll
+		time.Sleep(3 * followPollInterval)
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Error("followFiles() did not stop after cancellation while the file kept growing")
+		}
+	})
+}
+
+func TestRunLogTailSignalWhileGrowing(t *testing.T) {
+	tempDir := t.TempDir()
+	logFile := filepath.Join(tempDir, "busy.log")
+	state := filepath.Join(tempDir, "state.json")
+	if err := os.WriteFile(logFile, []byte("initial\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	filterPattern = ""
+	followMode = true
+	showSummary = true
+	stateFile = state
+	defer func() {
+		followMode = false
+		showSummary = false
+		stateFile = ""
+	}()
+
+	// The summary goes to stderr
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Cannot create pipe: %v", err)
+	}
+	originalStderr := os.Stderr
+	os.Stderr = w
+	defer func() {
+		os.Stderr = originalStderr
+	}()
+	summary := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		summary <- string(data)
+	}()
+
+	stopWriter := make(chan struct{})
+	writerDone := appendContinuously(t, logFile, stopWriter)
+	defer func() {
+		close(stopWriter)
+		<-writerDone
+	}()
+
+	captureStdout(t, func() {
+		done := make(chan error, 1)
+		go func() {
+			done <- runLogTail(nil, []string{logFile})
+		}()
+
+		time.Sleep(3 * followPollInterval)
+		syscall.Kill(os.Getpid(), syscall.SIGTERM)
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("runLogTail() unexpected error: %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("runLogTail() did not stop on SIGTERM while the file kept growing")
+		}
+	})
+	w.Close()
+
+	if text := <-summary; !strings.Contains(text, logFile) {
+		t.Errorf("Expected the summary to mention %s, got: %s", logFile, text)
+	}
+	if _, err := os.Stat(state); err != nil {
+		t.Errorf("Expected the state file to be saved on SIGTERM: %v", err)
+	}
+}
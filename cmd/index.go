@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"logtail/internal/checkpoint"
+	"logtail/internal/index"
+	"logtail/internal/input"
+
+	"github.com/spf13/cobra"
+)
+
+var indexInterval int64
+
+var indexCmd = &cobra.Command{
+	Use:   "index file...",
+	Short: "Build sidecar indexes for fast --since and --lines seeking",
+	Long: `Index samples the offset, line number and timestamp of a line roughly every
+--interval bytes and stores them next to the log as <file>.idx. Later
+--since and --lines +N queries on the file start reading near their target.
+Queries only use an index built with the same --assume-tz.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runIndex,
+}
+
+func init() {
+	indexCmd.Flags().Int64Var(&indexInterval, "interval", index.DefaultInterval, "Bytes between two index samples")
+	indexCmd.Flags().StringVar(&assumeTZFlag, "assume-tz", "", "Time zone of timestamps written without one (default UTC)")
+	rootCmd.AddCommand(indexCmd)
+}
+
+func runIndex(cmd *cobra.Command, args []string) error {
+	if err := parseTimeDisplayFlags(); err != nil {
+		return err
+	}
+	for _, filename := range args {
+		if err := buildIndex(filename); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildIndex indexes a plain log file and writes its sidecar index
+func buildIndex(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("cannot open file %s: %v", filename, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("error reading file %s: %v", filename, err)
+	}
+	if compressed, err := input.IsCompressed(file); err != nil || compressed {
+		return fmt.Errorf("cannot index %s: compressed files cannot be seeked", filename)
+	}
+
+	idx, err := index.Build(file, indexInterval)
+	if err != nil {
+		return fmt.Errorf("error reading file %s: %v", filename, err)
+	}
+	idx.Inode = checkpoint.Inode(info)
+
+	path := index.Path(filename)
+	if err := idx.Save(path); err != nil {
+		return fmt.Errorf("cannot write index %s: %v", path, err)
+	}
+
+	fmt.Printf("%s: %d samples written to %s\n", filename, len(idx.Samples), path)
+	return nil
+}
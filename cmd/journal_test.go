@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"logtail/internal/colorizer"
+)
+
+func TestProcessJournalExport(t *testing.T) {
+	originalLayout, originalColor, originalStdout := layout, colorOutput, stdout
+	defer func() {
+		layout, colorOutput, stdout = originalLayout, originalColor, originalStdout
+	}()
+	colorOutput = false
+	layout = colorizer.NewLayout(nil, []string{"__CURSOR", "_BOOT_ID"}, nil)
+
+	var buf strings.Builder
+	stdout = bufio.NewWriter(&buf)
+
+	export := "__CURSOR=s=6f1c;i=1\n__REALTIME_TIMESTAMP=1727692245123456\n_BOOT_ID=4d2e8a\n" +
+		"PRIORITY=4\nSYSLOG_IDENTIFIER=cron\n_PID=77\nMESSAGE=job took 12s\n\n" +
+		"__CURSOR=s=6f1c;i=2\n__REALTIME_TIMESTAMP=1727692246000000\nPRIORITY=3\n" +
+		"_SYSTEMD_UNIT=backup.service\nMESSAGE\n\x0b\x00\x00\x00\x00\x00\x00\x00backup\tfail\n\n"
+	if err := processLogs(context.Background(), strings.NewReader(export), newLogStream("-", "", nil)); err != nil {
+		t.Fatalf("processLogs failed: %v", err)
+	}
+	stdout.Flush()
+
+	want := "10:30:45.123 WARN  [cron] job took 12s" + strings.Repeat(" ", 28) + " _PID=77\n" +
+		"10:30:46.000 ERROR [backup.service] backup\tfail" + strings.Repeat(" ", 29) + " _SYSTEMD_UNIT=backup.service\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestProcessJournalExportBinary(t *testing.T) {
+	originalLayout, originalColor, originalStdout := layout, colorOutput, stdout
+	defer func() {
+		layout, colorOutput, stdout = originalLayout, originalColor, originalStdout
+	}()
+	colorOutput = false
+	layout = colorizer.NewLayout(nil, []string{"__CURSOR", "__MONOTONIC_TIMESTAMP", "_BOOT_ID"}, nil)
+
+	var buf strings.Builder
+	stdout = bufio.NewWriter(&buf)
+
+	export, err := os.Open("../internal/input/testdata/journal.export")
+	if err != nil {
+		t.Fatalf("Failed to open fixture: %v", err)
+	}
+	defer export.Close()
+	if err := processLogs(context.Background(), export, newLogStream("-", "", nil)); err != nil {
+		t.Fatalf("processLogs failed: %v", err)
+	}
+	stdout.Flush()
+
+	// The multi-line message and the binary field stay on their line, escaped
+	message := `backup failed:\n  disk full`
+	want := "10:30:45.123 INFO  [sshd] Accepted publickey for deploy from 10.0.0.7 port 52144 _PID=812 _SYSTEMD_UNIT=ssh.service\n" +
+		"10:30:46.500 ERROR [backup.service] " + message + strings.Repeat(" ", 40-len(message)) +
+		` _SYSTEMD_UNIT=backup.service RAW="\xff\x00\x01"` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output =\n%q\nwant\n%q", got, want)
+	}
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"logtail/internal/colorizer"
+)
+
+func TestOutputModes(t *testing.T) {
+	originalLayout, originalColor := layout, colorOutput
+	defer func() {
+		layout, colorOutput = originalLayout, originalColor
+	}()
+	colorOutput = false
+
+	log := `{"time":"2024-09-30T10:30:45.123Z","level":"info","msg":"served","path":"/users","status":200}` + "\n" +
+		`ts=2024-09-30T10:30:46Z level=error msg="query failed" table=users` + "\n" +
+		"2024-09-30 10:30:47 WARN plain text\n"
+
+	tests := []struct {
+		name     string
+		layout   *colorizer.Layout
+		expected []string
+	}{
+		{
+			name:   "Raw",
+			layout: nil,
+			expected: []string{
+				`{"time":"2024-09-30T10:30:45.123Z","level":"info","msg":"served","path":"/users","status":200}`,
+				`ts=2024-09-30T10:30:46Z level=error msg="query failed" table=users`,
+				"2024-09-30 10:30:47 WARN plain text",
+			},
+		},
+		{
+			name:   "Pretty",
+			layout: colorizer.NewLayout(nil, []string{"path"}, nil),
+			expected: []string{
+				"10:30:45.123 INFO  served" + strings.Repeat(" ", 34) + " status=200",
+				"10:30:46.000 ERROR query failed" + strings.Repeat(" ", 28) + " table=users",
+				"2024-09-30 10:30:47 WARN plain text",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout = tt.layout
+			got := runStream(t, log, false)
+			if want := strings.Join(tt.expected, "\n") + "\n"; got != want {
+				t.Errorf("output = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestRunLogTailOutputMode(t *testing.T) {
+	originalMode := outputMode
+	defer func() {
+		outputMode = originalMode
+	}()
+
+	outputMode = "yaml"
+	if err := runLogTail(rootCmd, []string{"testdata/does-not-matter.log"}); err == nil || !strings.Contains(err.Error(), "--output") {
+		t.Errorf("runLogTail() error = %v, want an invalid --output error", err)
+	}
+}
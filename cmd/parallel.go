@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+
+	"logtail/internal/input"
+	"logtail/internal/parser"
+)
+
+// parallelChunkSize is roughly how many bytes of whole lines each worker
+// handles at once
+var parallelChunkSize = 1 << 20
+
+// chunk is a run of whole lines handed to a worker
+type chunk struct {
+	data      []byte
+	firstLine int
+
+	// lines receives the parsed lines once the worker is done
+	lines chan []parsedLine
+}
+
+// parallelWorkers returns how many goroutines parse chunks, set by --workers
+func parallelWorkers() int {
+	if workers > 0 {
+		return workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// readChunks prints every remaining line of reader like readLines, but splits
+// the input into newline-aligned chunks parsed by several workers. Chunks are
+// written in their original order, and only the decisions that depend on the
+// previous lines, such as the time range of lines without timestamp, are made
+// in sequence.
+func readChunks(ctx context.Context, reader *input.LineReader, stream *logStream) error {
+	n := parallelWorkers()
+	work := make(chan *chunk, n)
+	// pending holds chunks in input order, bounding how far reading gets ahead
+	pending := make(chan *chunk, 2*n)
+
+	for i := 0; i < n; i++ {
+		go func() {
+			lineParser := parser.NewParser()
+			for c := range work {
+				c.lines <- parseChunk(c, stream, lineParser)
+			}
+		}()
+	}
+
+	var readErr error
+	go func() {
+		defer close(work)
+		defer close(pending)
+
+		lineNum := stream.lineNum
+		for ctx.Err() == nil {
+			data, err := reader.ReadChunk(parallelChunkSize)
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				readErr = err
+				return
+			}
+
+			c := &chunk{data: data, firstLine: lineNum, lines: make(chan []parsedLine, 1)}
+			lineNum += bytes.Count(data, []byte{'\n'})
+			if data[len(data)-1] != '\n' {
+				lineNum++
+			}
+
+			pending <- c
+			work <- c
+		}
+	}()
+
+	for c := range pending {
+		lines := <-c.lines
+		for _, line := range lines {
+			if output, ok := stream.emit(line); ok {
+				fmt.Fprintln(stdout, output)
+			}
+		}
+		stream.lineNum = c.firstLine + len(lines)
+	}
+
+	return readErr
+}
+
+// parseChunk splits a chunk into lines, truncated like those of readLines,
+// and parses them
+func parseChunk(c *chunk, stream *logStream, lineParser *parser.Parser) []parsedLine {
+	lines := input.NewLineReader(bytes.NewReader(c.data), maxLineLength)
+	parsed := make([]parsedLine, 0, bytes.Count(c.data, []byte{'\n'})+1)
+
+	for lineNum := c.firstLine; ; lineNum++ {
+		line, err := lines.ReadLine()
+		if err != nil {
+			// Reading from memory only ends with io.EOF
+			return parsed
+		}
+		parsed = append(parsed, stream.parse(lineParser, line, lineNum))
+	}
+}
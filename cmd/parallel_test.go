@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"logtail/internal/colorizer"
+	"logtail/internal/input"
+)
+
+// sampleLog returns n lines of a log with levels, timestamps and stack traces
+func sampleLog(n int) string {
+	var b strings.Builder
+	start := time.Date(2024, 9, 30, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		timestamp := start.Add(time.Duration(i) * time.Second).Format("2006-01-02 15:04:05")
+		switch i % 4 {
+		case 0:
+			fmt.Fprintf(&b, "%s INFO [api] request %d served from 10.0.0.%d in 12ms\n", timestamp, i, i%255)
+		case 1:
+			fmt.Fprintf(&b, "%s ERROR [db] query %d failed: see https://example.com/errors/%d\n", timestamp, i, i)
+		case 2:
+			fmt.Fprintf(&b, "    at com.example.Worker.run(Worker.java:%d)\n", i)
+		default:
+			fmt.Fprintf(&b, "%s DEBUG cache hit ratio %d%%\n", timestamp, i%100)
+		}
+	}
+	return b.String()
+}
+
+// runStream prints a log through readLines or readChunks and returns the output
+func runStream(t testing.TB, log string, chunked bool) string {
+	var buf strings.Builder
+	original := stdout
+	stdout = bufio.NewWriter(&buf)
+	defer func() {
+		stdout = original
+	}()
+
+	stream := newLogStream("test.log", "", nil)
+	reader := input.NewLineReader(strings.NewReader(log), maxLineLength)
+
+	var err error
+	if chunked {
+		err = readChunks(context.Background(), reader, stream)
+	} else {
+		err = readLines(context.Background(), reader, stream)
+	}
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stdout.Flush()
+	return buf.String()
+}
+
+func TestReadChunksMatchesReadLines(t *testing.T) {
+	originalChunkSize := parallelChunkSize
+	parallelChunkSize = 256
+	colorOutput = false
+	showLineNum = true
+	workers = 4
+	defer func() {
+		parallelChunkSize = originalChunkSize
+		showLineNum = false
+		workers = 0
+		sinceTime = time.Time{}
+		startLine = 0
+	}()
+
+	// No trailing newline, so that the last chunk ends mid-line
+	log := strings.TrimSuffix(sampleLog(200), "\n")
+
+	tests := []struct {
+		name      string
+		sinceTime time.Time
+		startLine int
+	}{
+		{name: "all lines"},
+		// Stack traces at chunk starts inherit the time of the previous chunk
+		{name: "since", sinceTime: time.Date(2024, 9, 30, 10, 1, 30, 0, time.UTC)},
+		{name: "lines", startLine: 57},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sinceTime, startLine = tt.sinceTime, tt.startLine
+
+			want := runStream(t, log, false)
+			got := runStream(t, log, true)
+			if got != want {
+				t.Errorf("readChunks() output differs from readLines()\ngot:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func benchmarkStream(b *testing.B, chunked bool) {
+	log := sampleLog(100000)
+	colorOutput = true
+	colorizer.SetEnabled(true)
+	defer func() {
+		colorOutput = false
+		colorizer.SetEnabled(false)
+	}()
+
+	b.SetBytes(int64(len(log)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		stream := newLogStream("bench.log", "", nil)
+		reader := input.NewLineReader(strings.NewReader(log), 0)
+
+		original := stdout
+		stdout = bufio.NewWriter(io.Discard)
+		if chunked {
+			readChunks(context.Background(), reader, stream)
+		} else {
+			readLines(context.Background(), reader, stream)
+		}
+		stdout = original
+	}
+}
+
+func BenchmarkReadLines(b *testing.B) {
+	benchmarkStream(b, false)
+}
+
+func BenchmarkReadChunks(b *testing.B) {
+	benchmarkStream(b, true)
+}
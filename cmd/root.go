@@ -2,13 +2,18 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"regexp"
+	"syscall"
 	"time"
 
+	"logtail/internal/checkpoint"
 	"logtail/internal/colorizer"
+	"logtail/internal/input"
 	"logtail/internal/parser"
 
 	"github.com/spf13/cobra"
@@ -16,9 +21,58 @@ import (
 
 var (
 	filterPattern string
-	colorOutput   bool
+	colorMode     string
 	followMode    bool
 	showLineNum   bool
+	rotatedMode   bool
+	maxLineLength int
+	skipBinary    bool
+	showSummary   bool
+	stateFile     string
+	sinceFlag     string
+	untilFlag     string
+	linesFlag     string
+	workers       int
+	themeName     string
+	configPath    string
+	hashColors    []string
+	hashPatterns  []string
+	expandMode    string
+	outputMode    string
+	showFields    []string
+	hideFields    []string
+	fieldOrder    []string
+	tzFlag        string
+	assumeTZFlag  string
+	timeFormat    string
+
+	partialLineTimeout time.Duration
+)
+
+var (
+	// colorOutput tells whether lines are colorized, as decided from --color
+	colorOutput bool
+
+	// layout renders JSON and logfmt entries with --output=pretty, nil with
+	// --output=raw
+	layout *colorizer.Layout
+
+	// hashFiles colors the file prefixes of follow mode by hash
+	hashFiles bool
+
+	// stdout buffers everything logtail prints; it is flushed on exit
+	stdout = bufio.NewWriter(os.Stdout)
+
+	// stats collects the end-of-session summary, nil unless --summary is set
+	stats *summary
+
+	// checkpoints holds the reading positions of --state-file, nil when unset
+	checkpoints *checkpoint.State
+
+	// sinceTime, untilTime and startLine select the part of the logs to show
+	sinceTime time.Time
+	untilTime time.Time
+	startLine int
 )
 
 var rootCmd = &cobra.Command{
@@ -26,6 +80,7 @@ var rootCmd = &cobra.Command{
 	Short: "An intelligent log analyzer for developers",
 	Long: `LogTail is a powerful command-line tool for parsing, filtering, and analyzing log files.
 It provides real-time filtering, syntax highlighting, and pattern detection.`,
+	Args: cobra.ArbitraryArgs,
 	RunE: runLogTail,
 }
 
@@ -35,12 +90,36 @@ func Execute() error {
 
 func init() {
 	rootCmd.Flags().StringVarP(&filterPattern, "filter", "f", "", "Filter logs with regex pattern")
-	rootCmd.Flags().BoolVarP(&colorOutput, "color", "c", true, "Enable colorized output")
+	rootCmd.Flags().StringVarP(&colorMode, "color", "c", "auto", "Colorize output: auto, always or never, written --color=never (-c alone means always)")
+	rootCmd.Flags().Lookup("color").NoOptDefVal = "always"
 	rootCmd.Flags().BoolVarP(&followMode, "follow", "F", false, "Follow log file like tail -f")
 	rootCmd.Flags().BoolVarP(&showLineNum, "line-numbers", "n", false, "Show line numbers")
+	rootCmd.Flags().IntVar(&maxLineLength, "max-line-length", 0, "Truncate lines longer than this many bytes (0 means no limit)")
+	rootCmd.Flags().BoolVar(&skipBinary, "skip-binary", false, "Skip inputs that look like binary data")
+	rootCmd.Flags().DurationVar(&partialLineTimeout, "partial-line-timeout", time.Second, "In follow mode, emit a line lacking its newline after this idle time")
+	rootCmd.Flags().BoolVar(&rotatedMode, "rotated", false, "Read rotated siblings of each file (app.log.1, app.log.2.gz...) oldest first")
+	rootCmd.Flags().BoolVar(&showSummary, "summary", false, "Print per-file and per-level line counts on exit")
+	rootCmd.Flags().StringVar(&sinceFlag, "since", "", "Show lines stamped at or after this time (2024-09-30T10:30:00Z, or a duration like 90m)")
+	rootCmd.Flags().StringVar(&untilFlag, "until", "", "Show lines stamped before this time")
+	rootCmd.Flags().StringVar(&linesFlag, "lines", "", "Start output at line N, written +N")
+	rootCmd.Flags().IntVar(&workers, "workers", 0, "Number of goroutines parsing files in batch mode (0 means one per CPU, 1 disables parallelism)")
+	rootCmd.Flags().StringVar(&themeName, "theme", "", "Color theme: dark, light, solarized, high-contrast or a theme of the config file")
+	rootCmd.Flags().StringVar(&configPath, "config", "", "Configuration file (default $XDG_CONFIG_HOME/logtail/config.json)")
+	rootCmd.Flags().StringSliceVar(&hashColors, "hash-colors", nil, "Give each value of these keys a stable color (e.g. request_id,trace_id); source and file stand for sources and file prefixes")
+	rootCmd.Flags().StringArrayVar(&hashPatterns, "hash-pattern", nil, "Give each match of this regex, or of its first group, a stable color")
+	rootCmd.Flags().StringVar(&expandMode, "expand-json", "", "Reformat JSON embedded in lines: pretty, indented below the line, or compact")
+	rootCmd.Flags().Lookup("expand-json").NoOptDefVal = expandPretty
+	rootCmd.Flags().StringVar(&outputMode, "output", "pretty", "How JSON and logfmt entries are printed: pretty or raw")
+	rootCmd.Flags().StringSliceVar(&showFields, "fields", nil, "Only show these fields of JSON and logfmt entries")
+	rootCmd.Flags().StringSliceVar(&hideFields, "hide-fields", nil, "Never show these fields of JSON and logfmt entries")
+	rootCmd.Flags().StringSliceVar(&fieldOrder, "field-order", nil, "Show these fields of JSON and logfmt entries first, in this order")
+	rootCmd.Flags().StringVar(&tzFlag, "tz", "", "Show timestamps in this time zone (local, UTC or a name like Europe/Paris)")
+	rootCmd.Flags().StringVar(&assumeTZFlag, "assume-tz", "", "Time zone of timestamps written without one (default UTC)")
+	rootCmd.Flags().StringVar(&timeFormat, "time-format", "", "Rewrite timestamps as iso, relative (3m12s ago) or elapsed (since the first line)")
+	rootCmd.Flags().StringVar(&stateFile, "state-file", "", "Resume each file where the previous run stopped, recording positions in this file")
 }
 
-func runLogTail(cmd *cobra.Command, args []string) error {
+func runLogTail(cmd *cobra.Command, args []string) (err error) {
 	// Compile filter pattern if provided
 	var filter *regexp.Regexp
 	if filterPattern != "" {
@@ -51,238 +130,463 @@ func runLogTail(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if err := parseTimeDisplayFlags(); err != nil {
+		return err
+	}
+	if err := parseRangeFlags(); err != nil {
+		return err
+	}
+	if err := checkExpandMode(expandMode); err != nil {
+		return err
+	}
+	switch outputMode {
+	case "pretty":
+		layout = colorizer.NewLayout(showFields, hideFields, fieldOrder)
+	case "raw":
+		layout = nil
+	default:
+		return fmt.Errorf("invalid --output mode %q: use pretty or raw", outputMode)
+	}
+
+	if cmd != nil {
+		if err := checkColorArgs(colorMode, cmd.Flags().Changed("color"), args); err != nil {
+			return err
+		}
+	}
+	colorOutput, err = colorEnabled(colorMode, os.Getenv, stdoutIsTerminal())
+	if err != nil {
+		return err
+	}
+	colorizer.SetEnabled(colorOutput)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if err := applyTheme(cfg); err != nil {
+		return err
+	}
+	if err := applyHashing(cfg); err != nil {
+		return err
+	}
+	if err := applyLevels(cfg); err != nil {
+		return err
+	}
+
+	// Stop cleanly on Ctrl-C or SIGTERM: flush output and print the summary
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	stdout = bufio.NewWriter(os.Stdout)
+	defer stdout.Flush()
+
+	stats = nil
+	if showSummary {
+		stats = newSummary()
+		defer func() {
+			// Print the summary after the last log lines
+			stdout.Flush()
+			stats.print(os.Stderr)
+		}()
+	}
+
+	checkpoints = nil
+	if stateFile != "" {
+		checkpoints, err = checkpoint.Load(stateFile)
+		if err != nil {
+			return fmt.Errorf("cannot load state file %s: %v", stateFile, err)
+		}
+		defer func() {
+			// Positions reached before an error or an interruption are still valid
+			if saveErr := checkpoints.Save(stateFile); saveErr != nil && err == nil {
+				err = fmt.Errorf("cannot save state file %s: %v", stateFile, saveErr)
+			}
+		}()
+	}
+
 	// Handle stdin case
 	if len(args) == 0 {
-		return processLogs(os.Stdin, filter, "", false)
+		reader, err := input.NewReader(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("cannot read stdin: %v", err)
+		}
+		defer reader.Close()
+		stream := newLogStream("(standard input)", "", filter)
+		stream.live = true
+		return processLogs(ctx, reader, stream)
 	}
 
 	// Follow mode only works with files
 	if followMode {
-		return followFiles(args, filter)
+		return followFiles(ctx, args, filter)
 	}
 
 	// Normal mode: process files sequentially
 	for i, filename := range args {
 		if len(args) > 1 {
-			fmt.Printf("==> %s <==\n", filename)
+			fmt.Fprintf(stdout, "==> %s <==\n", filename)
 		}
 
-		file, err := os.Open(filename)
-		if err != nil {
-			return fmt.Errorf("cannot open file %s: %v", filename, err)
+		// A rotated family is streamed as one log with continuous line numbers
+		stream := newLogStream(filename, "", filter)
+		switch {
+		case checkpoints != nil:
+			err = processWithState(ctx, filename, stream)
+		case seeking() && !rotatedMode:
+			var seeked bool
+			if seeked, err = processSeeking(ctx, filename, stream); err == nil && !seeked {
+				err = processFile(ctx, filename, stream)
+			}
+		default:
+			err = processFile(ctx, filename, stream)
 		}
-
-		err = processLogs(file, filter, filename, false)
-		file.Close()
-
 		if err != nil {
 			return err
 		}
 
+		if ctx.Err() != nil {
+			return nil
+		}
+
 		if i < len(args)-1 {
-			fmt.Println()
+			fmt.Fprintln(stdout)
 		}
 	}
 
 	return nil
 }
 
-type FollowFile struct {
-	file     *os.File
-	filename string
-	scanner  *bufio.Scanner
-	lineNum  int
-	position int64
+// parseRangeFlags parses --since, --until and --lines
+func parseRangeFlags() error {
+	var err error
+	now := time.Now()
+
+	sinceTime, untilTime, startLine = time.Time{}, time.Time{}, 0
+	if sinceFlag != "" {
+		if sinceTime, err = parseTimeFlag(sinceFlag, now); err != nil {
+			return err
+		}
+	}
+	if untilFlag != "" {
+		if untilTime, err = parseTimeFlag(untilFlag, now); err != nil {
+			return err
+		}
+	}
+	if linesFlag != "" {
+		if startLine, err = parseLinesFlag(linesFlag); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func followFiles(filenames []string, filter *regexp.Regexp) error {
-	// For follow mode, we need to track file positions and watch for changes
-	files := make([]*FollowFile, len(filenames))
+// logStream is one logical log being printed: a file, a rotated family or stdin
+type logStream struct {
+	name    string
+	prefix  string
+	filter  *regexp.Regexp
+	lineNum int
+
+	// lastTime is the latest timestamp seen, inherited by lines without one
+	// such as stack traces
+	lastTime time.Time
+
+	// firstTime is the first timestamp shown, from which
+	// --time-format=elapsed counts
+	firstTime time.Time
+
+	// parser caches the timestamp layout of the stream's lines
+	parser *parser.Parser
 
-	// Initialize files
-	for i, filename := range filenames {
-		file, err := os.Open(filename)
+	// live streams such as stdin are printed line by line as they arrive
+	// instead of in chunks
+	live bool
+
+	// fragments are the partial container records waiting for the one
+	// completing their line, fragmentMatched whether any passed the filter
+	fragments       []string
+	fragmentMatched bool
+
+	// meta are the container and pod fields derived from the stream's path
+	meta []parser.Field
+}
+
+func newLogStream(name, prefix string, filter *regexp.Regexp) *logStream {
+	return &logStream{
+		name:    name,
+		prefix:  prefix,
+		filter:  filter,
+		lineNum: 1,
+		parser:  parser.NewParser(),
+		meta:    containerFields(name),
+	}
+}
+
+func processLogs(ctx context.Context, reader io.Reader, stream *logStream) error {
+	// Journal exports are read as JSON lines
+	reader, err := input.NewJournalReader(reader)
+	if err != nil {
+		return err
+	}
+	lines := input.NewLineReader(reader, maxLineLength)
+
+	if skipBinary && lines.LooksBinary() {
+		fmt.Fprintf(os.Stderr, "logtail: skipping binary file %s\n", stream.name)
+		return nil
+	}
+
+	// Elapsed times depend on the lines before them
+	if !stream.live && parallelWorkers() > 1 && timeFormat != timeElapsed {
+		return readChunks(ctx, lines, stream)
+	}
+	return readLines(ctx, lines, stream)
+}
+
+// readLines prints every remaining line of reader, stopping early when ctx is cancelled
+func readLines(ctx context.Context, reader *input.LineReader, stream *logStream) error {
+	for ctx.Err() == nil {
+		line, err := reader.ReadLine()
+		if err == io.EOF {
+			return nil
+		}
 		if err != nil {
-			return fmt.Errorf("cannot open file %s: %v", filename, err)
+			return err
 		}
 
-		// First, read existing content from the beginning
-		scanner := bufio.NewScanner(file)
-		lineNum := 1
-
-		if len(filenames) > 1 {
-			fmt.Printf("==> %s <==\n", filename)
+		if output, ok := stream.format(line); ok {
+			fmt.Fprintln(stdout, output)
 		}
+	}
+	return nil
+}
 
-		// Process existing content
-		for scanner.Scan() {
-			line := scanner.Text()
+// format parses and colorizes the next line of the stream. It reports false
+// when the line is rejected by the filter.
+func (s *logStream) format(line string) (string, bool) {
+	lineNum := s.lineNum
+	s.lineNum++
+	return s.emit(s.parse(s.parser, line, lineNum))
+}
 
-			// Apply filter if defined
-			if filter != nil && !filter.MatchString(line) {
-				lineNum++
-				continue
-			}
+// parsedLine is a line parsed and formatted independently of the lines around
+// it, waiting for the decisions that depend on them
+type parsedLine struct {
+	lineNum   int
+	level     parser.LogLevel
+	timestamp time.Time
+
+	// parsed is false for lines skipped before parsing
+	parsed bool
+	// matched tells whether the line passed the filter
+	matched bool
+	// output is the formatted line, set when it may be shown
+	output string
+
+	// partial is set for the partial records of container logs, which emit
+	// keeps until the record completing their line
+	partial bool
+	// record is the line as read
+	record string
+}
+
+// parse does the stateless part of format, so that it can run on any
+// goroutine with its own parser. It only reads the stream's settings.
+func (s *logStream) parse(lineParser *parser.Parser, line string, lineNum int) parsedLine {
+	p := parsedLine{lineNum: lineNum, record: line}
+
+	// Lines before --lines +N are skipped without even being parsed
+	if lineNum < startLine {
+		return p
+	}
 
-			// Parse the log line
-			logEntry := parser.ParseLogLine(line)
+	// Apply filter if defined
+	p.matched = s.filter == nil || s.filter.MatchString(line)
+	timeRange := !sinceTime.IsZero() || !untilTime.IsZero()
+	if !p.matched && !timeRange && stats == nil {
+		p.partial = parser.IsPartialRecord(line)
+		return p
+	}
+
+	// Parse the log line
+	logEntry := lineParser.Parse(line)
+	p.parsed = true
+	p.partial = logEntry.Partial
+	if p.partial {
+		return p
+	}
+	return s.display(lineParser, p, logEntry)
+}
+
+// display sets the level, timestamp and output of a parsed line
+func (s *logStream) display(lineParser *parser.Parser, p parsedLine, logEntry parser.LogEntry) parsedLine {
+	p.level = logEntry.Level
+	p.timestamp = logEntry.Timestamp
+
+	// Lines stamped out of range are never shown, so skip their formatting.
+	// Lines without timestamp depend on the ones before them.
+	timeRange := !sinceTime.IsZero() || !untilTime.IsZero()
+	if !p.matched || (timeRange && !p.timestamp.IsZero() && !inTimeRange(p.timestamp)) {
+		return p
+	}
+
+	// Container records are displayed as the line they hold
+	line := logEntry.Raw
+	if len(s.meta) > 0 {
+		logEntry.Fields = append(logEntry.Fields[:len(logEntry.Fields):len(logEntry.Fields)], s.meta...)
+	}
 
-			// Display the line
-			output := line
+	// Display the line
+	var output string
+	if layout != nil && logEntry.Format != parser.FormatText {
+		timestamp, _ := s.displayTime(logEntry.Timestamp, "15:04:05.000")
+		output = layout.Render(logEntry, timestamp)
+	} else {
+		if timestamp, ok := s.displayTime(logEntry.Timestamp, isoLayout); ok {
+			logEntry, line = rewriteTimestamp(logEntry, line, timestamp)
+		}
+
+		expanded := false
+		if expandMode != "" {
+			output, expanded = s.expandJSON(lineParser, logEntry, line)
+		}
+		if !expanded {
+			output = line
 			if colorOutput {
 				output = colorizer.ColorizeLogLine(logEntry, line)
 			}
+		}
+	}
 
-			// Add filename prefix for multiple files
-			if len(filenames) > 1 {
-				prefix := fmt.Sprintf("[%s] ", filename)
-				if showLineNum {
-					fmt.Printf("%s%6d: %s\n", prefix, lineNum, output)
-				} else {
-					fmt.Printf("%s%s\n", prefix, output)
-				}
-			} else {
-				if showLineNum {
-					fmt.Printf("%6d: %s\n", lineNum, output)
-				} else {
-					fmt.Println(output)
-				}
-			}
+	if showLineNum {
+		p.output = fmt.Sprintf("%s%6d: %s", s.prefix, p.lineNum, output)
+	} else {
+		p.output = s.prefix + output
+	}
+	return p
+}
 
-			lineNum++
-		}
+// emit applies the decisions of format that depend on the previous lines of
+// the stream. Lines must be emitted in order.
+func (s *logStream) emit(p parsedLine) (string, bool) {
+	if p.partial {
+		s.fragments = append(s.fragments, p.record)
+		s.fragmentMatched = s.fragmentMatched || p.matched
+		return "", false
+	}
+	if len(s.fragments) > 0 {
+		p = s.join(p)
+	}
 
-		// Check for scanner errors
-		if err := scanner.Err(); err != nil {
-			file.Close()
-			return fmt.Errorf("error reading file %s: %v", filename, err)
-		}
+	if !p.parsed {
+		return "", false
+	}
 
-		// Get current position after reading existing content
-		position, _ := file.Seek(0, io.SeekCurrent)
+	if !p.timestamp.IsZero() {
+		s.lastTime = p.timestamp
+	}
 
-		files[i] = &FollowFile{
-			file:     file,
-			filename: filename,
-			scanner:  scanner,
-			lineNum:  lineNum,
-			position: position,
-		}
+	matched := p.matched
+	if (!sinceTime.IsZero() || !untilTime.IsZero()) && !inTimeRange(s.lastTime) {
+		matched = false
 	}
 
-	// Cleanup
-	defer func() {
-		for _, f := range files {
-			f.file.Close()
-		}
-	}()
+	if stats != nil {
+		stats.record(s.name, p.level, matched)
+	}
+	if !matched {
+		return "", false
+	}
+	return p.output, true
+}
 
-	// Follow loop
-	for {
-		hasNewContent := false
+// inTimeRange reports whether a line stamped t falls within --since and
+// --until. Lines before the first timestamp, with a zero t, are only shown
+// without --since.
+func inTimeRange(t time.Time) bool {
+	if !sinceTime.IsZero() && (t.IsZero() || t.Before(sinceTime)) {
+		return false
+	}
+	return untilTime.IsZero() || t.Before(untilTime)
+}
 
-		for _, f := range files {
-			// Check if file has grown
-			fileInfo, err := f.file.Stat()
-			if err != nil {
-				return fmt.Errorf("error getting file info for %s: %v", f.filename, err)
-			}
+// followPrefix returns the filename prefix used when following several files
+func followPrefix(filenames []string, filename string) string {
+	if len(filenames) <= 1 {
+		return ""
+	}
+	if hashFiles && colorOutput {
+		return "[" + colorizer.ColorizeByHash(filename) + "] "
+	}
+	return fmt.Sprintf("[%s] ", filename)
+}
 
-			currentSize := fileInfo.Size()
-			if currentSize > f.position {
-				// File has grown, seek to our last position and create new scanner
-				_, err := f.file.Seek(f.position, io.SeekStart)
-				if err != nil {
-					return fmt.Errorf("error seeking in file %s: %v", f.filename, err)
-				}
-				f.scanner = bufio.NewScanner(f.file)
-			}
+// rotatedFamily returns the rotated siblings of filename followed by the live file
+func rotatedFamily(filename string) ([]string, error) {
+	family, err := input.RotatedFamily(filename, maxLineLength)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list rotated files for %s: %v", filename, err)
+	}
+	if len(family) == 0 {
+		return nil, fmt.Errorf("cannot open file %s: no such file or rotated siblings", filename)
+	}
+	return family, nil
+}
 
-			// Read any new lines
-			for f.scanner.Scan() {
-				hasNewContent = true
-				line := f.scanner.Text()
-
-				// Update position after reading the line
-				f.position, _ = f.file.Seek(0, io.SeekCurrent)
-
-				// Apply filter if defined
-				if filter != nil && !filter.MatchString(line) {
-					f.lineNum++
-					continue
-				}
-
-				// Parse the log line
-				logEntry := parser.ParseLogLine(line)
-
-				// Display the line
-				output := line
-				if colorOutput {
-					output = colorizer.ColorizeLogLine(logEntry, line)
-				}
-
-				// Add filename prefix for multiple files
-				if len(filenames) > 1 {
-					prefix := fmt.Sprintf("[%s] ", f.filename)
-					if showLineNum {
-						fmt.Printf("%s%6d: %s\n", prefix, f.lineNum, output)
-					} else {
-						fmt.Printf("%s%s\n", prefix, output)
-					}
-				} else {
-					if showLineNum {
-						fmt.Printf("%6d: %s\n", f.lineNum, output)
-					} else {
-						fmt.Println(output)
-					}
-				}
-
-				f.lineNum++
-			}
+// processFile prints a file, or its whole rotated family with --rotated
+func processFile(ctx context.Context, filename string, stream *logStream) error {
+	sources := []string{filename}
+	if rotatedMode {
+		family, err := rotatedFamily(filename)
+		if err != nil {
+			return err
+		}
+		sources = family
+	}
 
-			// Check for scanner errors (but don't fail on EOF)
-			if err := f.scanner.Err(); err != nil {
-				return fmt.Errorf("error reading file %s: %v", f.filename, err)
-			}
+	for _, source := range sources {
+		file, err := input.Open(source)
+		if err != nil {
+			return fmt.Errorf("cannot open file %s: %v", source, err)
 		}
 
-		// If no new content, sleep briefly before checking again
-		if !hasNewContent {
-			time.Sleep(100 * time.Millisecond)
+		err = processLogs(ctx, file, stream)
+		file.Close()
+
+		if err != nil {
+			return err
 		}
 	}
-}
 
-func processLogs(reader io.Reader, filter *regexp.Regexp, filename string, isFollow bool) error {
-	scanner := bufio.NewScanner(reader)
-	lineNum := 1
+	return nil
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
+// processArchives streams the rotated archives of a live file, oldest first,
+// so that the live file continues the stream's line numbering
+func processArchives(ctx context.Context, stream *logStream) error {
+	family, err := rotatedFamily(stream.name)
+	if err != nil {
+		return err
+	}
 
-		// Apply filter if defined
-		if filter != nil && !filter.MatchString(line) {
-			lineNum++
+	for _, source := range family {
+		if source == stream.name {
+			// The live file, followed afterwards. It may be missing right
+			// after a rotation, the newest archive then coming last.
 			continue
 		}
 
-		// Parse the log line
-		logEntry := parser.ParseLogLine(line)
-
-		// Display the line
-		output := line
-		if colorOutput {
-			output = colorizer.ColorizeLogLine(logEntry, line)
+		file, err := input.Open(source)
+		if err != nil {
+			return fmt.Errorf("cannot open file %s: %v", source, err)
 		}
 
-		if showLineNum {
-			fmt.Printf("%6d: %s\n", lineNum, output)
-		} else {
-			fmt.Println(output)
-		}
+		err = processLogs(ctx, file, stream)
+		file.Close()
 
-		lineNum++
+		if err != nil {
+			return err
+		}
 	}
 
-	return scanner.Err()
+	return nil
 }
@@ -1,14 +1,20 @@
 package cmd
 
 import (
-	"bufio"
 	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"logtail/internal/input"
+
+	"github.com/klauspost/compress/zstd"
 	"github.com/spf13/cobra"
+	"github.com/ulikunitz/xz"
 )
 
 func TestRootCommand(t *testing.T) {
@@ -56,7 +62,7 @@ It provides real-time filtering, syntax highlighting, and pattern detection.`,
 			}
 
 			cmd.Flags().StringVarP(&filterPattern, "filter", "f", "", "Filter logs with regex pattern")
-			cmd.Flags().BoolVarP(&colorOutput, "color", "c", true, "Enable colorized output")
+			cmd.Flags().StringVarP(&colorMode, "color", "c", "auto", "Colorize output: auto, always or never")
 			cmd.Flags().BoolVarP(&followMode, "follow", "F", false, "Follow log file like tail -f")
 			cmd.Flags().BoolVarP(&showLineNum, "line-numbers", "n", false, "Show line numbers")
 
@@ -169,17 +175,182 @@ func TestFollowModeBasics(t *testing.T) {
 	files[0] = &FollowFile{
 		file:     file,
 		filename: testFile,
-		scanner:  bufio.NewScanner(file),
-		lineNum:  1,
+		reader:   input.NewLineReader(file, 0),
+		stream:   newLogStream(testFile, "", nil),
 	}
 
 	// Test that we can read the initial content
-	if !files[0].scanner.Scan() {
+	line, err := files[0].reader.ReadLine()
+	if err != nil {
 		t.Error("Should be able to read initial line")
 	}
 
-	line := files[0].scanner.Text()
 	if !strings.Contains(line, "Initial line") {
 		t.Errorf("Expected line to contain 'Initial line', got: %s", line)
 	}
 }
+
+// compressedLine is the content of the compressed logs of TestRunLogTailCompressed
+const compressedLine = "2024-09-30T10:30:45.123Z INFO Rotated line\n"
+
+// bzip2Line is compressedLine compressed with bzip2, as the stdlib has no bzip2 writer
+var bzip2Line = []byte{
+	0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0xa9, 0xd9,
+	0xd3, 0xf3, 0x00, 0x00, 0x07, 0x5f, 0x80, 0x00, 0x10, 0x40, 0x03, 0x7e,
+	0x30, 0x01, 0x21, 0x94, 0x10, 0x26, 0x25, 0x84, 0x00, 0x20, 0x00, 0x31,
+	0x4d, 0x1a, 0x00, 0xd0, 0x00, 0x05, 0x1a, 0x6a, 0x68, 0xc4, 0xf2, 0x4d,
+	0x3d, 0x04, 0xf2, 0x99, 0x4b, 0xe7, 0xb3, 0x32, 0xa1, 0x1b, 0xb0, 0x64,
+	0x36, 0xf3, 0x9e, 0x9c, 0x15, 0x17, 0xa5, 0xd1, 0x00, 0x49, 0xb0, 0xa0,
+	0xb2, 0x5f, 0x99, 0xbf, 0x17, 0x72, 0x45, 0x38, 0x50, 0x90, 0xa9, 0xd9,
+	0xd3, 0xf3,
+}
+
+func TestRunLogTailCompressed(t *testing.T) {
+	tempDir := t.TempDir()
+
+	var gzipped bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	gw.Write([]byte(compressedLine))
+	gw.Close()
+
+	var xzipped bytes.Buffer
+	xw, err := xz.NewWriter(&xzipped)
+	if err != nil {
+		t.Fatalf("xz writer failed: %v", err)
+	}
+	xw.Write([]byte(compressedLine))
+	xw.Close()
+
+	zw, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("zstd writer failed: %v", err)
+	}
+	zstdData := zw.EncodeAll([]byte(compressedLine), nil)
+	zw.Close()
+
+	followMode = false
+	filterPattern = ""
+	colorOutput = false
+	showLineNum = false
+
+	for name, data := range map[string][]byte{
+		"app.log.1.gz":  gzipped.Bytes(),
+		"app.log.2.bz2": bzip2Line,
+		"app.log.3.xz":  xzipped.Bytes(),
+		"app.log.4.zst": zstdData,
+	} {
+		testFile := filepath.Join(tempDir, name)
+		if err := os.WriteFile(testFile, data, 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+
+		output := captureStdout(t, func() {
+			if err := runLogTail(nil, []string{testFile}); err != nil {
+				t.Errorf("runLogTail should read compressed file %s: %v", name, err)
+			}
+		})
+		if output != compressedLine {
+			t.Errorf("runLogTail on %s printed %q, want %q", name, output, compressedLine)
+		}
+	}
+}
+
+func TestRunLogTailRotated(t *testing.T) {
+	tempDir := t.TempDir()
+	liveFile := filepath.Join(tempDir, "app.log")
+
+	files := map[string]string{
+		"app.log":   "2024-09-30T10:30:47.000Z INFO live\n",
+		"app.log.1": "2024-09-30T10:30:46.000Z INFO rotated once\n",
+		"app.log.2": "2024-09-30T10:30:45.000Z INFO rotated twice\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	followMode = false
+	rotatedMode = true
+	filterPattern = ""
+	colorOutput = false
+	showLineNum = true
+	defer func() {
+		rotatedMode = false
+		showLineNum = false
+	}()
+
+	output := captureStdout(t, func() {
+		if err := runLogTail(nil, []string{liveFile}); err != nil {
+			t.Errorf("runLogTail should read a rotated family: %v", err)
+		}
+	})
+
+	// Archives oldest first, then the live file, numbered as one stream
+	want := "     1: 2024-09-30T10:30:45.000Z INFO rotated twice\n" +
+		"     2: 2024-09-30T10:30:46.000Z INFO rotated once\n" +
+		"     3: 2024-09-30T10:30:47.000Z INFO live\n"
+	if output != want {
+		t.Errorf("runLogTail on a rotated family printed\n%q\nwant\n%q", output, want)
+	}
+
+	stream := newLogStream(liveFile, "", nil)
+	if err := processArchives(context.Background(), stream); err != nil {
+		t.Fatalf("processArchives() unexpected error: %v", err)
+	}
+	if stream.lineNum != 3 {
+		t.Errorf("processArchives() should continue numbering at 3, got %d", stream.lineNum)
+	}
+
+	// Right after a rotation the live file is missing: the newest archive
+	// must not be taken for it
+	if err := os.Remove(liveFile); err != nil {
+		t.Fatalf("Failed to remove live file: %v", err)
+	}
+	stream = newLogStream(liveFile, "", nil)
+	if err := processArchives(context.Background(), stream); err != nil {
+		t.Fatalf("processArchives() unexpected error: %v", err)
+	}
+	if stream.lineNum != 3 {
+		t.Errorf("processArchives() without a live file should read both archives, numbering up to 3, got %d", stream.lineNum)
+	}
+}
+
+func TestRunLogTailLongLines(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "dump.log")
+
+	// A single line well beyond bufio.Scanner's 64KB default limit
+	long := "2024-09-30T10:30:45.123Z INFO body=" + strings.Repeat("x", 2*1024*1024)
+	content := long + "\nnext line\n"
+	if err := os.WriteFile(testFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	followMode = false
+	filterPattern = ""
+	colorOutput = false
+	showLineNum = false
+
+	defer func() { maxLineLength = 0 }()
+
+	tests := []struct {
+		limit int
+		want  string
+	}{
+		{0, content},
+		{1024, long[:1024] + fmt.Sprintf(" [... truncated %d bytes]", len(long)-1024) + "\nnext line\n"},
+	}
+	for _, tt := range tests {
+		maxLineLength = tt.limit
+		output := captureStdout(t, func() {
+			if err := runLogTail(nil, []string{testFile}); err != nil {
+				t.Errorf("runLogTail with --max-line-length=%d should handle long lines: %v", tt.limit, err)
+			}
+		})
+		if output != tt.want {
+			t.Errorf("runLogTail with --max-line-length=%d printed %d bytes ending with %q, want %d ending with %q",
+				tt.limit, len(output), output[max(0, len(output)-60):], len(tt.want), tt.want[len(tt.want)-60:])
+		}
+	}
+}
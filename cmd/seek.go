@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"logtail/internal/checkpoint"
+	"logtail/internal/index"
+	"logtail/internal/input"
+)
+
+// timeFlagLayouts are the absolute forms accepted by --since and --until. Times
+// without a zone are in the zone of --assume-tz.
+var timeFlagLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseTimeFlag parses an absolute time or a duration counted back from now
+func parseTimeFlag(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range timeFlagLayouts {
+		if t, err := time.ParseInLocation(layout, value, assumedZone); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use a duration like 90m or a date like 2024-09-30T10:30:00Z", value)
+}
+
+// parseLinesFlag parses --lines, which only supports the tail-like +N form
+func parseLinesFlag(value string) (int, error) {
+	if !strings.HasPrefix(value, "+") {
+		return 0, fmt.Errorf("invalid --lines %q: use +N to start at line N", value)
+	}
+	n, err := strconv.Atoi(value[1:])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid --lines %q: use +N to start at line N", value)
+	}
+	return n, nil
+}
+
+// seeking reports whether --since or --lines allow skipping the start of files
+func seeking() bool {
+	return !sinceTime.IsZero() || startLine > 1
+}
+
+// seekPosition returns where reading a plain file may start for --since and
+// --lines. The sidecar index is used when it still describes the file and was
+// built assuming the same zone; otherwise --since falls back to a binary
+// search, which only moves when the log looks sorted. The line number is 0
+// when unknown.
+func seekPosition(file *os.File, info os.FileInfo) (int64, int, error) {
+	var idx *index.Index
+	if loaded, err := index.Load(index.Path(file.Name())); err == nil &&
+		loaded.Inode == checkpoint.Inode(info) && loaded.Size <= info.Size() &&
+		loaded.Zone == assumedZone.String() {
+		idx = loaded
+	}
+
+	best := index.Sample{Line: 1}
+	consider := func(sample index.Sample) {
+		if sample.Offset > best.Offset {
+			best = sample
+		}
+	}
+
+	if !sinceTime.IsZero() {
+		if idx != nil {
+			consider(idx.SeekTime(sinceTime))
+		} else {
+			offset, err := index.SearchTime(file, info.Size(), sinceTime)
+			if err != nil {
+				return 0, 0, err
+			}
+			consider(index.Sample{Offset: offset})
+		}
+	}
+
+	// Both samples precede their targets, so the furthest one skips nothing
+	// that either --since or --lines would have shown
+	if startLine > 1 && idx != nil {
+		consider(idx.SeekLine(startLine))
+	}
+
+	return best.Offset, best.Line, nil
+}
+
+// countLines counts the lines in the first n bytes of file, which is much
+// cheaper than parsing them
+func countLines(file *os.File, n int64) (int, error) {
+	buf := make([]byte, 256*1024)
+	count := 0
+	reader := io.NewSectionReader(file, 0, n)
+
+	for {
+		read, err := reader.Read(buf)
+		count += bytes.Count(buf[:read], []byte{'\n'})
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// processSeeking prints a plain file starting near the region selected by
+// --since and --lines. It reports false when the file cannot be seeked, in
+// which case nothing was printed.
+func processSeeking(ctx context.Context, filename string, stream *logStream) (bool, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return false, fmt.Errorf("cannot open file %s: %v", filename, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return false, nil
+	}
+	if compressed, err := input.IsCompressed(file); err != nil || compressed {
+		return false, nil
+	}
+
+	offset, line, err := seekPosition(file, info)
+	if err != nil {
+		return false, fmt.Errorf("error seeking in file %s: %v", filename, err)
+	}
+
+	// Line numbers after a binary search are only worth counting when needed
+	if line == 0 && (showLineNum || startLine > 1) {
+		count, err := countLines(file, offset)
+		if err != nil {
+			return false, fmt.Errorf("error reading file %s: %v", filename, err)
+		}
+		line = count + 1
+	}
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return false, fmt.Errorf("error seeking in file %s: %v", filename, err)
+	}
+
+	stream.lineNum = line
+	return true, processLogs(ctx, file, stream)
+}
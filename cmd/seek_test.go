@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"logtail/internal/parser"
+)
+
+func TestParseTimeFlag(t *testing.T) {
+	now := time.Date(2024, 9, 30, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value     string
+		want      time.Time
+		wantError bool
+	}{
+		{value: "90m", want: now.Add(-90 * time.Minute)},
+		{value: "2024-09-30T10:30:00Z", want: time.Date(2024, 9, 30, 10, 30, 0, 0, time.UTC)},
+		{value: "2024-09-30 10:30:00", want: time.Date(2024, 9, 30, 10, 30, 0, 0, time.UTC)},
+		{value: "2024-09-30", want: time.Date(2024, 9, 30, 0, 0, 0, 0, time.UTC)},
+		{value: "yesterday", wantError: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseTimeFlag(tt.value, now)
+		if tt.wantError != (err != nil) {
+			t.Errorf("parseTimeFlag(%q) error = %v, wantError %v", tt.value, err, tt.wantError)
+		}
+		if !tt.wantError && !got.Equal(tt.want) {
+			t.Errorf("parseTimeFlag(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseLinesFlag(t *testing.T) {
+	if n, err := parseLinesFlag("+42"); err != nil || n != 42 {
+		t.Errorf("parseLinesFlag(+42) = %d, %v", n, err)
+	}
+	for _, value := range []string{"42", "+0", "+x"} {
+		if _, err := parseLinesFlag(value); err == nil {
+			t.Errorf("parseLinesFlag(%q) expected error", value)
+		}
+	}
+}
+
+func TestRunLogTailSeeking(t *testing.T) {
+	tempDir := t.TempDir()
+	logFile := filepath.Join(tempDir, "app.log")
+	base := time.Date(2024, 9, 30, 10, 0, 0, 0, time.UTC)
+
+	var b strings.Builder
+	for i := 0; i < 20000; i++ {
+		fmt.Fprintf(&b, "%s INFO request %d\n", base.Add(time.Duration(i)*time.Second).Format(time.RFC3339), i)
+	}
+	if err := os.WriteFile(logFile, []byte(b.String()), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	followMode = false
+	filterPattern = ""
+	colorOutput = false
+	showLineNum = true
+	defer func() {
+		showLineNum = false
+		sinceFlag, untilFlag, linesFlag = "", "", ""
+	}()
+
+	tests := []struct {
+		name      string
+		since     string
+		until     string
+		lines     string
+		wantFirst string
+		wantCount int
+	}{
+		{
+			name:      "Since and until",
+			since:     base.Add(15000 * time.Second).Format(time.RFC3339),
+			until:     base.Add(15003 * time.Second).Format(time.RFC3339),
+			wantFirst: "15001: 2024-09-30T14:10:00Z INFO request 15000",
+			wantCount: 3,
+		},
+		{
+			name:      "Lines",
+			lines:     "+19998",
+			wantFirst: "19998: 2024-09-30T15:33:17Z INFO request 19997",
+			wantCount: 3,
+		},
+	}
+
+	run := func() string {
+		return captureStdout(t, func() {
+			if err := runLogTail(nil, []string{logFile}); err != nil {
+				t.Errorf("runLogTail() unexpected error: %v", err)
+			}
+		})
+	}
+
+	for _, indexed := range []bool{false, true} {
+		if indexed {
+			indexInterval = 16 * 1024
+			if err := buildIndex(logFile); err != nil {
+				t.Fatalf("buildIndex() unexpected error: %v", err)
+			}
+		}
+
+		for _, tt := range tests {
+			sinceFlag, untilFlag, linesFlag = tt.since, tt.until, tt.lines
+			lines := strings.Split(strings.TrimSpace(run()), "\n")
+
+			if len(lines) != tt.wantCount || strings.TrimSpace(lines[0]) != tt.wantFirst {
+				t.Errorf("%s (indexed: %v): got %d lines starting with %q, want %d starting with %q",
+					tt.name, indexed, len(lines), lines[0], tt.wantCount, tt.wantFirst)
+			}
+		}
+	}
+}
+
+func TestRunLogTailSeekingAssumedZone(t *testing.T) {
+	tempDir := t.TempDir()
+	logFile := filepath.Join(tempDir, "app.log")
+	base := time.Date(2024, 9, 30, 0, 0, 0, 0, time.UTC)
+
+	// Timestamps without a zone, one second apart from midnight
+	var b strings.Builder
+	for i := 0; i < 20000; i++ {
+		fmt.Fprintf(&b, "%s INFO request %d\n", base.Add(time.Duration(i)*time.Second).Format("2006-01-02 15:04:05"), i)
+	}
+	if err := os.WriteFile(logFile, []byte(b.String()), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	followMode = false
+	filterPattern = ""
+	colorOutput = false
+	indexInterval = 16 * 1024
+	defer func() {
+		sinceFlag, assumeTZFlag = "", ""
+		parser.SetNaiveZone(time.UTC)
+	}()
+
+	// 05:00 UTC is 01:00 in New York, where the log was written
+	const want = "2024-09-30 01:00:00 INFO request 3600"
+
+	for _, indexZone := range []string{"", "America/New_York"} {
+		assumeTZFlag = indexZone
+		captureStdout(t, func() {
+			if err := runIndex(nil, []string{logFile}); err != nil {
+				t.Fatalf("runIndex() unexpected error: %v", err)
+			}
+		})
+
+		assumeTZFlag, sinceFlag = "America/New_York", "2024-09-30T05:00:00Z"
+		output := captureStdout(t, func() {
+			if err := runLogTail(nil, []string{logFile}); err != nil {
+				t.Errorf("runLogTail() unexpected error: %v", err)
+			}
+		})
+		if first, _, _ := strings.Cut(output, "\n"); first != want {
+			t.Errorf("index built with --assume-tz %q: output starts with %q, want %q", indexZone, first, want)
+		}
+	}
+}
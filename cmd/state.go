@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"logtail/internal/checkpoint"
+	"logtail/internal/input"
+)
+
+// resumePlan tells where to continue reading a file from its saved state
+type resumePlan struct {
+	// previous is the file's former incarnation, rotated away since the last
+	// run, whose remaining lines come first
+	previous       string
+	previousOffset int64
+	// offset is where to start in the live file
+	offset int64
+}
+
+// fresh reports whether the plan reads the file from its beginning, as a new
+// file whose lines are numbered from 1
+func (p resumePlan) fresh() bool {
+	return p.previous == "" && p.offset == 0
+}
+
+// planResume compares a file with its saved state to detect truncation and
+// rotation since the last run
+func planResume(filename string, info os.FileInfo, saved checkpoint.FileState) resumePlan {
+	if checkpoint.Inode(info) == saved.Inode {
+		if info.Size() < saved.Offset {
+			// Truncated in place: everything in the file is new
+			return resumePlan{}
+		}
+		return resumePlan{offset: saved.Offset}
+	}
+
+	// Rotated: look for the file we were reading among the rotated siblings
+	family, err := input.RotatedFamily(filename, maxLineLength)
+	if err != nil {
+		return resumePlan{}
+	}
+	for _, sibling := range family {
+		siblingInfo, err := os.Stat(sibling)
+		if err != nil || checkpoint.Inode(siblingInfo) != saved.Inode || siblingInfo.Size() < saved.Offset {
+			continue
+		}
+		return resumePlan{previous: sibling, previousOffset: saved.Offset}
+	}
+
+	return resumePlan{}
+}
+
+// processFrom prints the complete lines of a plain file starting at offset and
+// returns the offset just past the last one. A trailing partial line is left
+// for the next run.
+func processFrom(ctx context.Context, filename string, offset int64, stream *logStream) (int64, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return 0, fmt.Errorf("cannot open file %s: %v", filename, err)
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("error seeking in file %s: %v", filename, err)
+	}
+
+	lines := input.NewLineReader(file, maxLineLength)
+	lines.SetOffset(offset)
+	lines.SetHoldPartial(true)
+
+	if err := readLines(ctx, lines, stream); err != nil {
+		return 0, fmt.Errorf("error reading file %s: %v", filename, err)
+	}
+
+	return lines.Offset() - int64(lines.Pending()), nil
+}
+
+// processWithState prints what was appended to filename since the last run,
+// including the end of its previous incarnation if it was rotated, and
+// records the new position
+func processWithState(ctx context.Context, filename string, stream *logStream) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("cannot open file %s: %v", filename, err)
+	}
+	info, err := file.Stat()
+	compressed := false
+	if err == nil {
+		compressed, err = input.IsCompressed(file)
+	}
+	file.Close()
+	if err != nil {
+		return fmt.Errorf("error reading file %s: %v", filename, err)
+	}
+
+	// Offsets into compressed archives are meaningless: read them whole
+	if compressed {
+		reader, err := input.Open(filename)
+		if err != nil {
+			return fmt.Errorf("cannot open file %s: %v", filename, err)
+		}
+		defer reader.Close()
+		return processLogs(ctx, reader, stream)
+	}
+
+	var offset int64
+	if saved, ok := checkpoints.Get(filename); ok {
+		plan := planResume(filename, info, saved)
+		if !plan.fresh() {
+			stream.lineNum = saved.Line
+		}
+
+		if plan.previous != "" {
+			if _, err := processFrom(ctx, plan.previous, plan.previousOffset, stream); err != nil {
+				return err
+			}
+		}
+		offset = plan.offset
+	} else if rotatedMode {
+		// First run: read the whole rotated family
+		if err := processArchives(ctx, stream); err != nil {
+			return err
+		}
+	}
+
+	end, err := processFrom(ctx, filename, offset, stream)
+	if err != nil {
+		return err
+	}
+
+	checkpoints.Set(filename, checkpoint.FileState{
+		Inode:  checkpoint.Inode(info),
+		Offset: end,
+		Line:   stream.lineNum,
+	})
+	return nil
+}
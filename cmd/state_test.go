@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func appendToFile(t *testing.T, name, content string) {
+	file, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("Cannot append to test file: %v", err)
+	}
+	defer file.Close()
+	file.WriteString(content)
+}
+
+func TestRunLogTailStateFile(t *testing.T) {
+	tempDir := t.TempDir()
+	logFile := filepath.Join(tempDir, "app.log")
+	state := filepath.Join(tempDir, "state.json")
+
+	followMode = false
+	filterPattern = ""
+	colorOutput = false
+	showLineNum = true
+	stateFile = state
+	defer func() {
+		showLineNum = false
+		stateFile = ""
+	}()
+
+	run := func() string {
+		return captureStdout(t, func() {
+			if err := runLogTail(nil, []string{logFile}); err != nil {
+				t.Errorf("runLogTail() unexpected error: %v", err)
+			}
+		})
+	}
+
+	steps := []struct {
+		name    string
+		prepare func()
+		want    string
+	}{
+		{
+			name:    "First run reads everything but a partial line",
+			prepare: func() { appendToFile(t, logFile, "one\ntwo\nthr") },
+			want:    "     1: one\n     2: two\n",
+		},
+		{
+			name:    "Second run resumes from the checkpoint",
+			prepare: func() { appendToFile(t, logFile, "ee\nfour\n") },
+			want:    "     3: three\n     4: four\n",
+		},
+		{
+			name:    "Nothing new",
+			prepare: func() {},
+			want:    "",
+		},
+		{
+			name: "Rotation since the last run",
+			prepare: func() {
+				appendToFile(t, logFile, "five\n")
+				os.Rename(logFile, logFile+".1")
+				appendToFile(t, logFile, "six\n")
+			},
+			want: "     5: five\n     6: six\n",
+		},
+		{
+			name:    "Truncation since the last run",
+			prepare: func() { os.WriteFile(logFile, []byte("7\n"), 0644) },
+			want:    "     1: 7\n",
+		},
+	}
+
+	for _, step := range steps {
+		step.prepare()
+		if got := run(); got != step.want {
+			t.Errorf("%s: output = %q, want %q", step.name, got, step.want)
+		}
+	}
+
+	data, err := os.ReadFile(state)
+	if err != nil || !strings.Contains(string(data), `"line": 2`) {
+		t.Errorf("Expected state file to record the next line number, got %s (%v)", data, err)
+	}
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"logtail/internal/parser"
+)
+
+// summaryLevels is the order in which levels appear in the summary
+var summaryLevels = []parser.LogLevel{
+	parser.LevelFatal,
+	parser.LevelCritical,
+	parser.LevelError,
+	parser.LevelWarn,
+	parser.LevelNotice,
+	parser.LevelInfo,
+	parser.LevelDebug,
+	parser.LevelTrace,
+	parser.LevelUnknown,
+}
+
+// lineCounts counts the lines seen and those that passed the filter
+type lineCounts struct {
+	seen    int
+	matched int
+}
+
+type fileSummary struct {
+	lines     lineCounts
+	rotations int
+}
+
+// summary accumulates the statistics printed at the end of a session. It is
+// safe for concurrent use by the followers of several files.
+type summary struct {
+	mu     sync.Mutex
+	start  time.Time
+	files  map[string]*fileSummary
+	order  []string
+	levels map[parser.LogLevel]*lineCounts
+}
+
+func newSummary() *summary {
+	return &summary{
+		start:  time.Now(),
+		files:  make(map[string]*fileSummary),
+		levels: make(map[parser.LogLevel]*lineCounts),
+	}
+}
+
+// file returns the statistics of a file, creating them on first use.
+// The caller must hold the lock.
+func (s *summary) file(name string) *fileSummary {
+	f, ok := s.files[name]
+	if !ok {
+		f = &fileSummary{}
+		s.files[name] = f
+		s.order = append(s.order, name)
+	}
+	return f
+}
+
+// record counts one line of the named file
+func (s *summary) record(name string, level parser.LogLevel, matched bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts, ok := s.levels[level]
+	if !ok {
+		counts = &lineCounts{}
+		s.levels[level] = counts
+	}
+
+	f := s.file(name)
+	f.lines.seen++
+	counts.seen++
+	if matched {
+		f.lines.matched++
+		counts.matched++
+	}
+}
+
+// rotated counts a rotation or truncation of the named file
+func (s *summary) rotated(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.file(name).rotations++
+}
+
+// print writes the summary as two aligned tables, per file and per level
+func (s *summary) print(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fmt.Fprintf(w, "\n==> Summary (%s) <==\n", time.Since(s.start).Round(time.Millisecond))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tSEEN\tMATCHED\tROTATIONS")
+	for _, name := range s.order {
+		f := s.files[name]
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\n", name, f.lines.seen, f.lines.matched, f.rotations)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(tw, "LEVEL\tSEEN\tMATCHED")
+	for _, level := range summaryLevels {
+		if counts, ok := s.levels[level]; ok {
+			fmt.Fprintf(tw, "%s\t%d\t%d\n", level, counts.seen, counts.matched)
+		}
+	}
+	tw.Flush()
+}
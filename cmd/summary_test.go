@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"logtail/internal/parser"
+)
+
+func TestSummary(t *testing.T) {
+	s := newSummary()
+
+	s.record("app.log", parser.LevelError, true)
+	s.record("app.log", parser.LevelInfo, false)
+	s.record("app.log", parser.LevelInfo, true)
+	s.record("db.log", parser.LevelError, true)
+	s.rotated("db.log")
+
+	var buf bytes.Buffer
+	s.print(&buf)
+	output := buf.String()
+
+	expectedRows := [][]string{
+		{"FILE", "SEEN", "MATCHED", "ROTATIONS"},
+		{"app.log", "3", "2", "0"},
+		{"db.log", "1", "1", "1"},
+		{"LEVEL", "SEEN", "MATCHED"},
+		{"ERROR", "2", "2"},
+		{"INFO", "2", "1"},
+	}
+
+	lines := strings.Split(output, "\n")
+	for _, row := range expectedRows {
+		found := false
+		for _, line := range lines {
+			if strings.Join(strings.Fields(line), " ") == strings.Join(row, " ") {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("Expected summary to contain row %v, got:\n%s", row, output)
+		}
+	}
+
+	if !strings.Contains(output, "==> Summary (") {
+		t.Errorf("Expected summary header with duration, got:\n%s", output)
+	}
+	if strings.Contains(output, "WARN") {
+		t.Errorf("Levels never seen should be omitted, got:\n%s", output)
+	}
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"logtail/internal/parser"
+)
+
+// Values of --time-format
+const (
+	timeISO      = "iso"
+	timeRelative = "relative"
+	timeElapsed  = "elapsed"
+)
+
+// isoLayout is the layout of rewritten timestamps unless --time-format says otherwise
+const isoLayout = "2006-01-02T15:04:05.000Z07:00"
+
+var (
+	// displayZone is the zone of --tz, nil to show timestamps in their own zone
+	displayZone *time.Location
+
+	// assumedZone is the zone of --assume-tz, given to timestamps written
+	// without one
+	assumedZone = time.UTC
+)
+
+// loadZone returns the zone named by a flag: local, UTC or an IANA name
+func loadZone(flag, name string) (*time.Location, error) {
+	switch strings.ToLower(name) {
+	case "local":
+		return time.Local, nil
+	case "utc":
+		return time.UTC, nil
+	}
+	zone, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %v", flag, name, err)
+	}
+	return zone, nil
+}
+
+// parseTimeDisplayFlags parses --tz, --assume-tz and --time-format. It must
+// run before parseRangeFlags, whose times without a zone are in the assumed
+// zone too.
+func parseTimeDisplayFlags() error {
+	var err error
+
+	displayZone, assumedZone = nil, time.UTC
+	if tzFlag != "" {
+		if displayZone, err = loadZone("--tz", tzFlag); err != nil {
+			return err
+		}
+	}
+	if assumeTZFlag != "" {
+		if assumedZone, err = loadZone("--assume-tz", assumeTZFlag); err != nil {
+			return err
+		}
+	}
+	parser.SetNaiveZone(assumedZone)
+
+	switch timeFormat {
+	case "", timeISO, timeRelative, timeElapsed:
+		return nil
+	}
+	return fmt.Errorf("invalid --time-format %q: use iso, relative or elapsed", timeFormat)
+}
+
+// displayTime returns the text shown for a timestamp, in the given layout
+// unless --time-format says otherwise. ok is false when timestamps are shown
+// as they are written, with neither --tz nor --time-format.
+func (s *logStream) displayTime(t time.Time, layout string) (string, bool) {
+	if t.IsZero() || (displayZone == nil && timeFormat == "") {
+		return "", false
+	}
+	if displayZone != nil {
+		t = t.In(displayZone)
+	}
+
+	switch timeFormat {
+	case timeRelative:
+		return relativeTime(t, time.Now()), true
+	case timeElapsed:
+		// Streams are parsed in order with --time-format=elapsed
+		if s.firstTime.IsZero() {
+			s.firstTime = t
+		}
+		return elapsedTime(t.Sub(s.firstTime)), true
+	case timeISO:
+		layout = isoLayout
+	}
+	return t.Format(layout), true
+}
+
+// relativeTime returns how long before now t is, as in "3m12s ago"
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t).Truncate(time.Second)
+	if d < 0 {
+		return "in " + shortDuration(-d)
+	}
+	return shortDuration(d) + " ago"
+}
+
+// shortDuration writes a duration with its two most significant units
+func shortDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", d/time.Second)
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", d/time.Minute, d%time.Minute/time.Second)
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%02dm", d/time.Hour, d%time.Hour/time.Minute)
+	default:
+		return fmt.Sprintf("%dd%02dh", d/(24*time.Hour), d%(24*time.Hour)/time.Hour)
+	}
+}
+
+// elapsedTime writes the time elapsed since the first timestamp of a stream,
+// as in +01:02:03.456
+func elapsedTime(d time.Duration) string {
+	sign := "+"
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	return fmt.Sprintf("%s%02d:%02d:%02d.%03d", sign,
+		d/time.Hour, d%time.Hour/time.Minute, d%time.Minute/time.Second, d%time.Second/time.Millisecond)
+}
+
+// rewriteTimestamp replaces the timestamp of a parsed line with text, and
+// moves the spans of the entry that follow it accordingly. The text is quoted
+// where an unquoted value could not hold it, such as a number in JSON.
+func rewriteTimestamp(entry parser.LogEntry, line, text string) (parser.LogEntry, string) {
+	span := entry.TimestampSpan
+	quoted := span.Start > 0 && line[span.Start-1] == '"'
+	switch entry.Format {
+	case parser.FormatJSON, parser.FormatJournal, parser.FormatGELF:
+		if !quoted {
+			text = strconv.Quote(text)
+		}
+	case parser.FormatLogfmt:
+		if !quoted && strings.ContainsRune(text, ' ') {
+			text = strconv.Quote(text)
+		}
+	}
+	line = line[:span.Start] + text + line[span.End:]
+
+	shift := len(text) - (span.End - span.Start)
+	for _, s := range []*parser.Span{&entry.LevelSpan, &entry.SourceSpan} {
+		if !s.IsEmpty() && s.Start >= span.End {
+			s.Start += shift
+			s.End += shift
+		}
+	}
+	entry.TimestampSpan.End += shift
+	entry.Raw = line
+	return entry, line
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"logtail/internal/parser"
+)
+
+func TestShortTimes(t *testing.T) {
+	now := time.Date(2024, 9, 30, 12, 0, 0, 0, time.UTC)
+	relative := []struct {
+		t    time.Time
+		want string
+	}{
+		{now.Add(-42 * time.Second), "42s ago"},
+		{now.Add(-(3*time.Minute + 12*time.Second + 500*time.Millisecond)), "3m12s ago"},
+		{now.Add(-(2*time.Hour + 5*time.Minute)), "2h05m ago"},
+		{now.Add(-(50 * time.Hour)), "2d02h ago"},
+		{now.Add(90 * time.Second), "in 1m30s"},
+	}
+	for _, tt := range relative {
+		if got := relativeTime(tt.t, now); got != tt.want {
+			t.Errorf("relativeTime(%v) = %q, want %q", tt.t, got, tt.want)
+		}
+	}
+
+	elapsed := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "+00:00:00.000"},
+		{time.Hour + 2*time.Minute + 3*time.Second + 456*time.Millisecond, "+01:02:03.456"},
+		{-1500 * time.Millisecond, "-00:00:01.500"},
+	}
+	for _, tt := range elapsed {
+		if got := elapsedTime(tt.d); got != tt.want {
+			t.Errorf("elapsedTime(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestRewriteTimestamp(t *testing.T) {
+	line := "2024-09-30 10:30:45 ERROR [db] Connection refused"
+	entry, got := rewriteTimestamp(parser.ParseLogLine(line), line, "+00:00:01.000")
+
+	if want := "+00:00:01.000 ERROR [db] Connection refused"; got != want {
+		t.Fatalf("rewriteTimestamp() line = %q, want %q", got, want)
+	}
+	for name, tt := range map[string]struct {
+		span parser.Span
+		want string
+	}{
+		"timestamp": {entry.TimestampSpan, "+00:00:01.000"},
+		"level":     {entry.LevelSpan, "ERROR"},
+		"source":    {entry.SourceSpan, "[db]"},
+	} {
+		if text := got[tt.span.Start:tt.span.End]; text != tt.want {
+			t.Errorf("%s span covers %q, want %q", name, text, tt.want)
+		}
+	}
+}
+
+func TestRewriteStructuredTimestamp(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{
+			line: `{"ts":1727692245,"level":"error","msg":"failed"}`,
+			want: `{"ts":"748d11h ago","level":"error","msg":"failed"}`,
+		},
+		{
+			line: `{"ts":"2024-09-30T10:30:45Z","level":"error","msg":"failed"}`,
+			want: `{"ts":"748d11h ago","level":"error","msg":"failed"}`,
+		},
+		{
+			line: `ts=2024-09-30T10:30:45Z level=error msg=failed`,
+			want: `ts="748d11h ago" level=error msg=failed`,
+		},
+		{
+			line: `ts="2024-09-30T10:30:45Z" level=error msg=failed`,
+			want: `ts="748d11h ago" level=error msg=failed`,
+		},
+	}
+
+	for _, tt := range tests {
+		entry, got := rewriteTimestamp(parser.ParseLogLine(tt.line), tt.line, "748d11h ago")
+		if got != tt.want {
+			t.Errorf("rewriteTimestamp(%q) line = %q, want %q", tt.line, got, tt.want)
+		}
+		if text := got[entry.LevelSpan.Start:entry.LevelSpan.End]; text != "error" {
+			t.Errorf("rewriteTimestamp(%q) level span covers %q, want error", tt.line, text)
+		}
+		if reparsed := parser.ParseLogLine(got); reparsed.Format != entry.Format {
+			t.Errorf("rewriteTimestamp(%q) = %q, no longer parsed as %v", tt.line, got, entry.Format)
+		}
+	}
+}
+
+func TestTimeDisplay(t *testing.T) {
+	originalTZ, originalAssume, originalFormat, originalColor := tzFlag, assumeTZFlag, timeFormat, colorOutput
+	defer func() {
+		tzFlag, assumeTZFlag, timeFormat, colorOutput = originalTZ, originalAssume, originalFormat, originalColor
+		parseTimeDisplayFlags()
+	}()
+	colorOutput = false
+
+	log := "2024-09-30T10:30:45.123Z INFO started\n" +
+		"    at main.go:12\n" +
+		"2024-09-30 10:31:00 WARN slow\n"
+
+	tests := []struct {
+		name     string
+		tz       string
+		assumeTZ string
+		format   string
+		expected []string
+	}{
+		{
+			name:     "Unchanged",
+			expected: []string{"2024-09-30T10:30:45.123Z INFO started", "    at main.go:12", "2024-09-30 10:31:00 WARN slow"},
+		},
+		{
+			name:     "Time zone",
+			tz:       "Asia/Tokyo",
+			expected: []string{"2024-09-30T19:30:45.123+09:00 INFO started", "    at main.go:12", "2024-09-30T19:31:00.000+09:00 WARN slow"},
+		},
+		{
+			name:     "Assumed zone",
+			assumeTZ: "Asia/Tokyo",
+			format:   timeISO,
+			expected: []string{"2024-09-30T10:30:45.123Z INFO started", "    at main.go:12", "2024-09-30T10:31:00.000+09:00 WARN slow"},
+		},
+		{
+			name:     "Elapsed",
+			format:   timeElapsed,
+			expected: []string{"+00:00:00.000 INFO started", "    at main.go:12", "+00:00:14.877 WARN slow"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tzFlag, assumeTZFlag, timeFormat = tt.tz, tt.assumeTZ, tt.format
+			if err := parseTimeDisplayFlags(); err != nil {
+				t.Skipf("time zone database unavailable: %v", err)
+			}
+			got := runStream(t, log, false)
+			if want := strings.Join(tt.expected, "\n") + "\n"; got != want {
+				t.Errorf("output = %q, want %q", got, want)
+			}
+		})
+	}
+
+	for _, flags := range [][3]string{{"Mars/Olympus", "", ""}, {"", "nowhere", ""}, {"", "", "epoch"}} {
+		tzFlag, assumeTZFlag, timeFormat = flags[0], flags[1], flags[2]
+		if err := parseTimeDisplayFlags(); err == nil {
+			t.Errorf("parseTimeDisplayFlags() with %q returned no error", flags)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileState records how far a file has been read
+type FileState struct {
+	// Inode identifies the file, so that a rotation since the last run is noticed
+	Inode uint64 `json:"inode"`
+	// Offset is the byte offset just past the last complete line read
+	Offset int64 `json:"offset"`
+	// Line is the number of the next line to read
+	Line int `json:"line"`
+}
+
+// State holds the reading positions of every file seen by previous runs
+type State struct {
+	Files map[string]FileState `json:"files"`
+}
+
+// Load reads the state file at path. A missing file yields an empty state.
+func Load(path string) (*State, error) {
+	state := &State{Files: make(map[string]FileState)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("invalid state file: %v", err)
+	}
+	if state.Files == nil {
+		state.Files = make(map[string]FileState)
+	}
+
+	return state, nil
+}
+
+// Save writes the state to path atomically: readers either see the previous
+// state or the new one, never a partially written file
+func (s *State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get returns the saved state of the named file
+func (s *State) Get(name string) (FileState, bool) {
+	state, ok := s.Files[key(name)]
+	return state, ok
+}
+
+// Set records the state of the named file
+func (s *State) Set(name string, state FileState) {
+	s.Files[key(name)] = state
+}
+
+// key identifies files by absolute path, so that runs from different
+// working directories share their checkpoints
+func key(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return name
+}
@@ -0,0 +1,87 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadMissingFile(t *testing.T) {
+	state, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("Load() unexpected error for missing file: %v", err)
+	}
+	if len(state.Files) != 0 {
+		t.Errorf("Load() expected empty state, got %v", state.Files)
+	}
+}
+
+func TestLoadInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("not json"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	if _, err := Load(path); err == nil {
+		t.Error("Load() expected error for invalid state file")
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	state, _ := Load(path)
+	state.Set(filepath.Join(dir, "app.log"), FileState{Inode: 42, Offset: 1024, Line: 17})
+
+	if err := state.Save(path); err != nil {
+		t.Fatalf("Save() unexpected error: %v", err)
+	}
+
+	// No temporary file may be left behind
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Save() left %d files in the directory, want 1", len(entries))
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+
+	got, ok := loaded.Get(filepath.Join(dir, "app.log"))
+	if !ok {
+		t.Fatal("Get() did not find the saved file")
+	}
+	if got != (FileState{Inode: 42, Offset: 1024, Line: 17}) {
+		t.Errorf("Get() = %+v, want the saved state", got)
+	}
+}
+
+func TestInode(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.log")
+	second := filepath.Join(dir, "second.log")
+
+	for _, name := range []string{first, second} {
+		if err := os.WriteFile(name, []byte("line\n"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	firstInfo, _ := os.Stat(first)
+	secondInfo, _ := os.Stat(second)
+
+	// Renaming a file keeps its identity
+	if err := os.Rename(first, first+".1"); err != nil {
+		t.Fatalf("Cannot rename test file: %v", err)
+	}
+	renamedInfo, _ := os.Stat(first + ".1")
+
+	if Inode(firstInfo) != Inode(renamedInfo) {
+		t.Error("Inode() changed across a rename")
+	}
+	if Inode(firstInfo) != 0 && Inode(firstInfo) == Inode(secondInfo) {
+		t.Error("Inode() is the same for two different files")
+	}
+}
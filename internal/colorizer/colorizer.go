@@ -1,8 +1,8 @@
 package colorizer
 
 import (
-	"regexp"
-	"strings"
+	"fmt"
+	"sort"
 
 	"logtail/internal/parser"
 
@@ -10,96 +10,147 @@ import (
 )
 
 var (
-	// Color functions for different elements
-	errorColor     = color.New(color.FgRed, color.Bold)
-	warnColor      = color.New(color.FgYellow, color.Bold)
-	infoColor      = color.New(color.FgCyan)
-	debugColor     = color.New(color.FgMagenta)
-	timestampColor = color.New(color.FgBlue)
-	sourceColor    = color.New(color.FgGreen)
-
-	// Patterns to identify special elements
-	urlPattern    = regexp.MustCompile(`https?://[^\s]+`)
-	ipPattern     = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
-	numberPattern = regexp.MustCompile(`\b\d+\b`)
+	// Color functions for different elements, set by SetTheme. A nil color
+	// leaves its element uncolored.
+	fatalColor     *color.Color
+	criticalColor  *color.Color
+	errorColor     *color.Color
+	warnColor      *color.Color
+	noticeColor    *color.Color
+	infoColor      *color.Color
+	debugColor     *color.Color
+	traceColor     *color.Color
+	timestampColor *color.Color
+	sourceColor    *color.Color
+
+	plainColor = color.New()
+
+	// Colors of the tokens found in the text of lines
+	urlColor          *color.Color
+	ipColor           *color.Color
+	keyColor          *color.Color
+	valueColor        *color.Color
+	numberColor       *color.Color
+	quotedColor       *color.Color
+	durationColor     *color.Color
+	uuidColor         *color.Color
+	errorKeywordColor *color.Color
+	warnKeywordColor  *color.Color
 )
 
-// ColorizeLogLine colorizes a log line based on its parsed content
+// enabled tells whether lines are colorized at all. It starts from the
+// terminal detection of fatih/color, and is then set explicitly by SetEnabled.
+var enabled = !color.NoColor
+
+func init() {
+	plainColor.EnableColor()
+	if err := SetTheme(Themes[DefaultTheme], Depth16); err != nil {
+		panic(err)
+	}
+}
+
+// SetEnabled turns colorization on or off. Colors are decided here only, not
+// by the global color.NoColor of fatih/color.
+func SetEnabled(on bool) {
+	enabled = on
+}
+
+// Enabled reports whether lines are colorized
+func Enabled() bool {
+	return enabled
+}
+
+// ColorizeLogLine colorizes a log line based on its parsed content. In lines
+// with a level, the level color is kept for the level badge, while the
+// timestamp, source and the tokens of the message get their own styles.
 func ColorizeLogLine(entry parser.LogEntry, originalLine string) string {
-	line := originalLine
+	if !enabled {
+		return originalLine
+	}
 
-	// Colorize according to log level
-	switch entry.Level {
-	case parser.LevelError, parser.LevelFatal:
-		line = errorColor.Sprint(line)
-	case parser.LevelWarn:
-		line = warnColor.Sprint(line)
-	case parser.LevelInfo:
-		line = infoColor.Sprint(line)
-	case parser.LevelDebug, parser.LevelTrace:
-		line = debugColor.Sprint(line)
-	default:
-		// Apply specific colorizations if no level detected
-		line = colorizeSpecialPatterns(line)
+	// Apply specific colorizations if no level detected
+	if entry.Level == parser.LevelUnknown {
+		return colorizeSpecialPatterns(originalLine)
 	}
 
-	return line
+	return render(originalLine, entrySpans(entry, originalLine))
 }
 
-// colorizeSpecialPatterns colors special patterns in the line
-func colorizeSpecialPatterns(line string) string {
-	// Colorize URLs
-	line = urlPattern.ReplaceAllStringFunc(line, func(url string) string {
-		return color.BlueString(url)
-	})
-
-	// Colorize IP addresses
-	line = ipPattern.ReplaceAllStringFunc(line, func(ip string) string {
-		return color.CyanString(ip)
-	})
-
-	// Identify and colorize error keywords even without formal level
-	errorKeywords := []string{"error", "exception", "failed", "failure", "panic", "fatal"}
-	for _, keyword := range errorKeywords {
-		if strings.Contains(strings.ToLower(line), keyword) {
-			pattern := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(keyword) + `\b`)
-			line = pattern.ReplaceAllStringFunc(line, func(match string) string {
-				return errorColor.Sprint(match)
-			})
+// entrySpans returns the spans of a leveled line: its parsed parts, and the
+// tokens found in the text around them
+func entrySpans(entry parser.LogEntry, line string) []span {
+	var parts []span
+	// The parsed spans are only valid for the line they were parsed from
+	if entry.Raw == line {
+		source := spanSource
+		if hashing.Source {
+			source = spanHashed
 		}
-	}
 
-	// Identify and colorize warning keywords
-	warnKeywords := []string{"warning", "warn", "deprecated", "obsolete"}
-	for _, keyword := range warnKeywords {
-		if strings.Contains(strings.ToLower(line), keyword) {
-			pattern := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(keyword) + `\b`)
-			line = pattern.ReplaceAllStringFunc(line, func(match string) string {
-				return warnColor.Sprint(match)
-			})
+		for _, part := range []span{
+			{start: entry.TimestampSpan.Start, end: entry.TimestampSpan.End, kind: spanTimestamp},
+			{start: entry.LevelSpan.Start, end: entry.LevelSpan.End, kind: spanLevel, level: entry.Level},
+			{start: entry.SourceSpan.Start, end: entry.SourceSpan.End, kind: source},
+		} {
+			if part.end > part.start {
+				parts = append(parts, part)
+			}
 		}
+		sort.Slice(parts, func(i, j int) bool { return parts[i].start < parts[j].start })
 	}
 
-	return line
+	return lineSpans(line, parts, false)
+}
+
+// colorizeSpecialPatterns colors URLs, IP addresses, error and warning
+// keywords, numbers and quoted strings. They are all found in one pass over
+// the plain text and rendered once, so no pattern ever matches inside the
+// escape sequences inserted for another.
+func colorizeSpecialPatterns(line string) string {
+	if !enabled {
+		return line
+	}
+	return render(line, findSpans(line))
 }
 
 // ColorizeByLevel returns a coloring function based on the level
 func ColorizeByLevel(level parser.LogLevel) func(...interface{}) string {
+	if !enabled {
+		return fmt.Sprint
+	}
+	if c := levelColor(level); c != nil {
+		return c.Sprint
+	}
+	return plainColor.Sprint
+}
+
+// levelColor returns the color of a level, nil when it is left uncolored
+func levelColor(level parser.LogLevel) *color.Color {
 	switch level {
-	case parser.LevelError, parser.LevelFatal:
-		return errorColor.Sprint
+	case parser.LevelFatal:
+		return fatalColor
+	case parser.LevelCritical:
+		return criticalColor
+	case parser.LevelError:
+		return errorColor
 	case parser.LevelWarn:
-		return warnColor.Sprint
+		return warnColor
+	case parser.LevelNotice:
+		return noticeColor
 	case parser.LevelInfo:
-		return infoColor.Sprint
-	case parser.LevelDebug, parser.LevelTrace:
-		return debugColor.Sprint
+		return infoColor
+	case parser.LevelDebug:
+		return debugColor
+	case parser.LevelTrace:
+		return traceColor
 	default:
-		return color.New().Sprint
+		return nil
 	}
 }
 
-// DisableColor disables coloring (useful for pipes and redirections)
+// DisableColor disables coloring (useful for pipes and redirections), here
+// and in every other user of fatih/color
 func DisableColor() {
+	enabled = false
 	color.NoColor = true
 }
@@ -11,11 +11,9 @@ import (
 
 func TestColorizeLogLine(t *testing.T) {
 	// Disable color for consistent testing
-	originalNoColor := color.NoColor
-	color.NoColor = true
-	defer func() {
-		color.NoColor = originalNoColor
-	}()
+	originalEnabled := Enabled()
+	SetEnabled(false)
+	defer SetEnabled(originalEnabled)
 
 	tests := []struct {
 		name         string
@@ -115,11 +113,9 @@ func TestColorizeLogLine(t *testing.T) {
 
 func TestColorizeSpecialPatterns(t *testing.T) {
 	// Disable color for consistent testing
-	originalNoColor := color.NoColor
-	color.NoColor = true
-	defer func() {
-		color.NoColor = originalNoColor
-	}()
+	originalEnabled := Enabled()
+	SetEnabled(false)
+	defer SetEnabled(originalEnabled)
 
 	tests := []struct {
 		name     string
@@ -279,10 +275,14 @@ func TestDisableColor(t *testing.T) {
 	}()
 
 	// Test enabling color first
+	originalEnabled := Enabled()
+	defer SetEnabled(originalEnabled)
+
 	color.NoColor = false
+	SetEnabled(true)
 	DisableColor()
 
-	if !color.NoColor {
+	if !color.NoColor || Enabled() {
 		t.Error("DisableColor() did not disable colors")
 	}
 }
@@ -356,9 +356,40 @@ func BenchmarkColorizeLogLine(b *testing.B) {
 
 func BenchmarkColorizeSpecialPatterns(b *testing.B) {
 	line := "ERROR: Failed to connect to https://api.example.com from 192.168.1.100"
+	originalEnabled := Enabled()
+	SetEnabled(true)
+	defer SetEnabled(originalEnabled)
 
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		colorizeSpecialPatterns(line)
 	}
 }
+
+func TestSetEnabled(t *testing.T) {
+	originalNoColor, originalEnabled := color.NoColor, Enabled()
+	defer func() {
+		color.NoColor = originalNoColor
+		SetEnabled(originalEnabled)
+	}()
+
+	line := "2024-09-30 ERROR Database connection failed"
+	entry := parser.ParseLogLine(line)
+
+	// The colorizer decides on its own, whatever fatih/color detected
+	color.NoColor = true
+	SetEnabled(true)
+	if result := ColorizeLogLine(entry, line); result == line {
+		t.Error("ColorizeLogLine() did not color with colors enabled")
+	}
+
+	color.NoColor = false
+	SetEnabled(false)
+	if result := ColorizeLogLine(entry, line); result != line {
+		t.Errorf("ColorizeLogLine() = %q with colors disabled, want the line unchanged", result)
+	}
+	if result := ColorizeByLevel(parser.LevelError)("text"); result != "text" {
+		t.Errorf("ColorizeByLevel() = %q with colors disabled, want the text unchanged", result)
+	}
+}
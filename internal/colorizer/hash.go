@@ -0,0 +1,119 @@
+package colorizer
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/fatih/color"
+)
+
+// Hashing selects values colored by hash: each value gets a color picked from
+// a palette by hashing it, so that every occurrence of the same request ID or
+// source shares a color, across lines and files
+type Hashing struct {
+	// Keys are the keys of key=value pairs whose values are hashed, such as request_id
+	Keys []string
+	// Patterns are regular expressions whose first capture group, or whole
+	// match when they have none, is hashed
+	Patterns []*regexp.Regexp
+	// Source hashes the source of entries
+	Source bool
+}
+
+var (
+	// hashKeys and hashing are the active hashing settings
+	hashKeys = map[string]bool{}
+	hashing  Hashing
+
+	// hashPalette holds the colors values are hashed to, set with the theme
+	// for the depth of the terminal
+	hashPalette []*color.Color
+)
+
+// hashIndexes are 256-color palette entries distinct enough from each other,
+// and readable on dark and light backgrounds
+var hashIndexes = []int{
+	33, 37, 40, 43, 63, 69, 71, 75, 99, 105, 109, 113, 129, 135, 139, 143,
+	166, 168, 172, 176, 178, 184, 202, 208,
+}
+
+// hashBasic are the basic colors used on 16-color terminals, red being left
+// to errors
+var hashBasic = []color.Attribute{
+	color.FgGreen, color.FgYellow, color.FgBlue, color.FgMagenta, color.FgCyan,
+	color.FgHiGreen, color.FgHiYellow, color.FgHiBlue, color.FgHiMagenta, color.FgHiCyan,
+}
+
+// SetHashing selects the values colored by hash
+func SetHashing(h Hashing) {
+	hashing = h
+	hashKeys = make(map[string]bool, len(h.Keys))
+	for _, key := range h.Keys {
+		hashKeys[key] = true
+	}
+}
+
+// setHashPalette builds the hash palette for the depth of the terminal
+func setHashPalette(depth Depth) {
+	hashPalette = hashPalette[:0]
+	if depth == Depth16 {
+		for _, attribute := range hashBasic {
+			hashPalette = append(hashPalette, color.New(attribute))
+		}
+	} else {
+		for _, index := range hashIndexes {
+			hashPalette = append(hashPalette, color.New(38, 5, color.Attribute(index)))
+		}
+	}
+	for _, c := range hashPalette {
+		c.EnableColor()
+	}
+}
+
+// hashColor returns the color of a value, from its 32-bit FNV-1a hash
+func hashColor(value string) *color.Color {
+	h := uint32(2166136261)
+	for i := 0; i < len(value); i++ {
+		h ^= uint32(value[i])
+		h *= 16777619
+	}
+	return hashPalette[h%uint32(len(hashPalette))]
+}
+
+// ColorizeByHash colors text with the color of its hash, as used for values
+// selected by SetHashing
+func ColorizeByHash(text string) string {
+	if !enabled {
+		return text
+	}
+	return hashColor(text).Sprint(text)
+}
+
+// patternSpans returns the hashed spans of the hashing patterns that do not
+// overlap parts, which must be sorted
+func patternSpans(line string, parts []span) []span {
+	var spans []span
+	for _, pattern := range hashing.Patterns {
+		for _, match := range pattern.FindAllStringSubmatchIndex(line, -1) {
+			start, end := match[0], match[1]
+			if len(match) > 2 && match[2] >= 0 {
+				start, end = match[2], match[3]
+			}
+			if end > start && !overlaps(parts, start, end) && !overlaps(spans, start, end) {
+				spans = append(spans, span{start: start, end: end, kind: spanHashed})
+			}
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	return spans
+}
+
+// overlaps reports whether a span of spans overlaps line[start:end]
+func overlaps(spans []span, start, end int) bool {
+	for _, s := range spans {
+		if s.start < end && start < s.end {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package colorizer
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"logtail/internal/parser"
+)
+
+func TestHashColorStable(t *testing.T) {
+	defer SetTheme(Themes[DefaultTheme], Depth16)
+
+	for _, depth := range []Depth{Depth16, Depth256} {
+		SetTheme(Themes[DefaultTheme], depth)
+
+		if hashColor("req-42") != hashColor("req-42") {
+			t.Errorf("hashColor() differs for the same value at depth %v", depth)
+		}
+
+		// Several values must not all collapse onto one color
+		distinct := make(map[string]bool)
+		for _, value := range []string{"a1", "b2", "c3", "d4", "e5", "f6", "g7", "h8"} {
+			distinct[hashColor(value).Sprint("x")] = true
+		}
+		if len(distinct) < 3 {
+			t.Errorf("hashColor() gave %d colors to 8 values at depth %v", len(distinct), depth)
+		}
+	}
+}
+
+func TestHashedSpans(t *testing.T) {
+	defer SetHashing(Hashing{})
+	SetHashing(Hashing{
+		Keys:     []string{"request_id", "user"},
+		Patterns: []*regexp.Regexp{regexp.MustCompile(`trace:([0-9a-f]+)`)},
+		Source:   true,
+	})
+
+	line := `2024-09-30 10:30:45 INFO [api] request_id=abc-123 user="bob smith" status=200 trace:9f8e7d`
+	entry := parser.ParseLogLine(line)
+
+	var hashed []string
+	for _, s := range entrySpans(entry, line) {
+		if s.kind == spanHashed {
+			hashed = append(hashed, line[s.start:s.end])
+		}
+	}
+
+	want := []string{"[api]", "abc-123", `"bob smith"`, "9f8e7d"}
+	if strings.Join(hashed, "|") != strings.Join(want, "|") {
+		t.Errorf("hashed spans = %q, want %q", hashed, want)
+	}
+}
+
+func TestColorizeByHash(t *testing.T) {
+	originalEnabled := Enabled()
+	defer SetEnabled(originalEnabled)
+
+	SetEnabled(false)
+	if got := ColorizeByHash("app.log"); got != "app.log" {
+		t.Errorf("ColorizeByHash() = %q with colors disabled, want the text unchanged", got)
+	}
+
+	SetEnabled(true)
+	if got := ColorizeByHash("app.log"); got == "app.log" || ansiPattern.ReplaceAllString(got, "") != "app.log" {
+		t.Errorf("ColorizeByHash() = %q, want app.log colored", got)
+	}
+}
@@ -0,0 +1,49 @@
+package colorizer
+
+import (
+	"strings"
+
+	"logtail/internal/parser"
+)
+
+// ColorizeJSON colors a JSON document, compact or indented: keys, strings
+// and numbers get their theme styles, while braces, brackets and separators
+// take the color of the level of the entry holding the document
+func ColorizeJSON(text string, level parser.LogLevel) string {
+	if !enabled {
+		return text
+	}
+
+	var spans []span
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '"':
+			end := quotedEnd(text, i)
+			if end == 0 {
+				end = len(text)
+			}
+			kind := spanQuoted
+			if rest := strings.TrimLeft(text[end:], " \t\r\n"); strings.HasPrefix(rest, ":") {
+				kind = spanKey
+			}
+			spans = append(spans, span{start: i, end: end, kind: kind})
+			i = end
+		case strings.IndexByte("{}[],:", c) >= 0:
+			spans = append(spans, span{start: i, end: i + 1, kind: spanLevel, level: level})
+			i++
+		case c == '-' || isDigit(c) || c >= 'a' && c <= 'z':
+			// Numbers, true, false and null
+			end := i + 1
+			for end < len(text) && strings.IndexByte(" \t\r\n,:]}", text[end]) < 0 {
+				end++
+			}
+			spans = append(spans, span{start: i, end: end, kind: spanNumber})
+			i = end
+		default:
+			i++
+		}
+	}
+
+	return render(text, spans)
+}
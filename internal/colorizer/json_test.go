@@ -0,0 +1,39 @@
+package colorizer
+
+import (
+	"strings"
+	"testing"
+
+	"logtail/internal/parser"
+)
+
+func TestColorizeJSON(t *testing.T) {
+	originalEnabled := Enabled()
+	defer SetEnabled(originalEnabled)
+
+	text := "{\n  \"code\": 500,\n  \"retry\": true,\n  \"errors\": [\"a: b\", null]\n}"
+
+	SetEnabled(false)
+	if got := ColorizeJSON(text, parser.LevelError); got != text {
+		t.Errorf("ColorizeJSON() = %q with colors disabled, want the text unchanged", got)
+	}
+
+	SetEnabled(true)
+	got := ColorizeJSON(text, parser.LevelError)
+	if plain := ansiPattern.ReplaceAllString(got, ""); plain != text {
+		t.Errorf("ColorizeJSON() changed the text: got %q, want %q", plain, text)
+	}
+
+	for _, want := range []string{
+		keyColor.Sprint(`"code"`),
+		numberColor.Sprint("500"),
+		numberColor.Sprint("null"),
+		quotedColor.Sprint(`"a: b"`),
+		errorColor.Sprint("{"),
+		errorColor.Sprint(":"),
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("ColorizeJSON() = %q, want it to contain %q", got, want)
+		}
+	}
+}
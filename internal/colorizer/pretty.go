@@ -0,0 +1,201 @@
+package colorizer
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"logtail/internal/parser"
+)
+
+// messageWidth is the width messages are padded to, so that the fields of
+// successive entries line up
+const messageWidth = 40
+
+// Layout renders structured entries for humans, as
+// "15:04:05.000 LEVEL [source] message key=value...", and selects and orders
+// the fields shown
+type Layout struct {
+	only   map[string]bool
+	hidden map[string]bool
+	order  map[string]int
+}
+
+// NewLayout returns a Layout showing only the given fields when any, never the
+// hidden ones, and the ones of order first, in this order. The other fields
+// follow in their order in the line.
+func NewLayout(fields, hidden, order []string) *Layout {
+	l := &Layout{
+		hidden: make(map[string]bool, len(hidden)),
+		order:  make(map[string]int, len(order)),
+	}
+	if len(fields) > 0 {
+		l.only = make(map[string]bool, len(fields))
+		for _, key := range fields {
+			l.only[key] = true
+		}
+	}
+	for _, key := range hidden {
+		l.hidden[key] = true
+	}
+	for i, key := range order {
+		if _, ok := l.order[key]; !ok {
+			l.order[key] = i
+		}
+	}
+	return l
+}
+
+// Render renders an entry, colored when colors are enabled. The time column
+// shows timestamp, or the time of the entry as 15:04:05.000 when empty.
+func (l *Layout) Render(entry parser.LogEntry, timestamp string) string {
+	var b strings.Builder
+	var spans []span
+
+	// add writes a column of the given kind
+	add := func(text string, kind spanKind) {
+		start := b.Len()
+		b.WriteString(text)
+		if kind != 0 && text != "" {
+			spans = append(spans, span{start: start, end: b.Len(), kind: kind, level: entry.Level})
+		}
+	}
+
+	switch {
+	case timestamp != "":
+		add(timestamp, spanTimestamp)
+	case entry.Timestamp.IsZero():
+		add(strings.Repeat(" ", len("15:04:05.000")), 0)
+	default:
+		add(entry.Timestamp.Format("15:04:05.000"), spanTimestamp)
+	}
+	b.WriteByte(' ')
+
+	level := ""
+	if entry.Level != parser.LevelUnknown {
+		level = string(entry.Level)
+	}
+	add(level, spanLevel)
+	b.WriteString(strings.Repeat(" ", max(0, 5-len(level))))
+
+	if entry.Source != "" {
+		b.WriteString(" [")
+		kind := spanSource
+		if hashing.Source {
+			kind = spanHashed
+		}
+		add(entry.Source, kind)
+		b.WriteByte(']')
+	}
+
+	fields := l.fields(entry.Fields)
+	message := messageText(entry.Message)
+	if message != "" || len(fields) > 0 {
+		b.WriteByte(' ')
+	}
+	start := b.Len()
+	b.WriteString(message)
+	spans = scanSpans(spans, b.String(), start, false)
+
+	for i, field := range fields {
+		if i == 0 {
+			b.WriteString(strings.Repeat(" ", max(0, messageWidth-utf8.RuneCountInString(message))))
+		}
+		b.WriteByte(' ')
+		add(field.Key, spanKey)
+		b.WriteByte('=')
+
+		value, kind := fieldValue(field.Value), spanValue
+		switch {
+		case hashKeys[field.Key]:
+			kind = spanHashed
+		case value != field.Value:
+			kind = spanQuoted
+		}
+		add(value, kind)
+	}
+
+	if !enabled {
+		return b.String()
+	}
+	return render(b.String(), spans)
+}
+
+// fields returns the fields of an entry to show, in order
+func (l *Layout) fields(fields []parser.Field) []parser.Field {
+	shown := make([]parser.Field, 0, len(fields))
+	for _, field := range fields {
+		if (l.only == nil || l.only[field.Key]) && !l.hidden[field.Key] {
+			shown = append(shown, field)
+		}
+	}
+
+	if len(l.order) > 0 {
+		sort.SliceStable(shown, func(i, j int) bool {
+			return l.rank(shown[i].Key) < l.rank(shown[j].Key)
+		})
+	}
+	return shown
+}
+
+// rank returns the position of a key in the field order, fields out of it
+// coming last
+func (l *Layout) rank(key string) int {
+	if i, ok := l.order[key]; ok {
+		return i
+	}
+	return len(l.order)
+}
+
+// fieldValue returns a value as written in a key=value pair: quoted when
+// empty or holding spaces, unless it is JSON text, and when it holds bytes
+// that cannot be shown, as binary journald fields do
+func fieldValue(value string) string {
+	if value == "" {
+		return `""`
+	}
+	if !printable(value) {
+		return strconv.Quote(value)
+	}
+	if value[0] == '{' || value[0] == '[' {
+		return value
+	}
+	if strings.ContainsAny(value, " \t\r\n\"=") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// messageText returns a message as shown on one line: its newlines, control
+// characters and bytes that are not UTF-8 are escaped as in Go strings
+func messageText(message string) string {
+	if printable(message) {
+		return message
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(message); {
+		r, size := utf8.DecodeRuneInString(message[i:])
+		if (r == utf8.RuneError && size == 1) || unprintable(r) {
+			quoted := strconv.Quote(message[i : i+size])
+			b.WriteString(quoted[1 : len(quoted)-1])
+		} else {
+			b.WriteString(message[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// printable reports whether text is valid UTF-8 holding no unprintable rune
+func printable(text string) bool {
+	return utf8.ValidString(text) && strings.IndexFunc(text, unprintable) < 0
+}
+
+// unprintable reports whether a rune cannot be shown as is on a line. Tabs
+// can, and are only quoted in values.
+func unprintable(r rune) bool {
+	return r != '\t' && !unicode.IsGraphic(r)
+}
@@ -0,0 +1,80 @@
+package colorizer
+
+import (
+	"strings"
+	"testing"
+
+	"logtail/internal/parser"
+)
+
+func TestLayoutRender(t *testing.T) {
+	originalEnabled := Enabled()
+	defer SetEnabled(originalEnabled)
+	SetEnabled(false)
+
+	line := `{"time":"2024-09-30T10:30:45.123Z","level":"warn","logger":"api","msg":"slow request","path":"/users","user":"bob smith","ms":1200}`
+	entry := parser.ParseLogLine(line)
+	pad := strings.Repeat(" ", messageWidth-len("slow request"))
+
+	tests := []struct {
+		name   string
+		layout *Layout
+		want   string
+	}{
+		{
+			name:   "All fields",
+			layout: NewLayout(nil, nil, nil),
+			want:   `10:30:45.123 WARN  [api] slow request` + pad + ` path=/users user="bob smith" ms=1200`,
+		},
+		{
+			name:   "Selected fields",
+			layout: NewLayout([]string{"ms", "user"}, nil, nil),
+			want:   `10:30:45.123 WARN  [api] slow request` + pad + ` user="bob smith" ms=1200`,
+		},
+		{
+			name:   "Hidden fields and order",
+			layout: NewLayout(nil, []string{"path"}, []string{"ms"}),
+			want:   `10:30:45.123 WARN  [api] slow request` + pad + ` ms=1200 user="bob smith"`,
+		},
+		{
+			name:   "No fields left",
+			layout: NewLayout(nil, []string{"path", "user", "ms"}, nil),
+			want:   `10:30:45.123 WARN  [api] slow request`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.layout.Render(entry, ""); got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	bare := parser.ParseLogLine(`level=info msg=started`)
+	if got, want := NewLayout(nil, nil, nil).Render(bare, ""), "             INFO  started"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestLayoutRenderColors(t *testing.T) {
+	originalEnabled := Enabled()
+	defer SetEnabled(originalEnabled)
+	SetEnabled(true)
+
+	entry := parser.ParseLogLine(`level=error ts=2024-09-30T10:30:45Z msg="query failed" retries=3`)
+	got := NewLayout(nil, nil, nil).Render(entry, "")
+
+	if plain := ansiPattern.ReplaceAllString(got, ""); !strings.HasSuffix(plain, " retries=3") {
+		t.Errorf("Render() changed the text: got %q", plain)
+	}
+	for _, want := range []string{
+		timestampColor.Sprint("10:30:45.000"),
+		errorColor.Sprint("ERROR"),
+		keyColor.Sprint("retries"),
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Render() = %q, want it to contain %q", got, want)
+		}
+	}
+}
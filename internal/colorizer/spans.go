@@ -0,0 +1,417 @@
+package colorizer
+
+import (
+	"sort"
+	"strings"
+
+	"logtail/internal/parser"
+
+	"github.com/fatih/color"
+)
+
+// spanKind is the kind of token a span highlights
+type spanKind uint8
+
+const (
+	spanURL spanKind = iota + 1
+	spanIP
+	spanErrorKeyword
+	spanWarnKeyword
+	spanNumber
+	spanQuoted
+	spanDuration
+	spanUUID
+	spanKey
+	spanValue
+	spanTimestamp
+	spanSource
+	spanLevel
+	// spanHashed is colored by the hash of its text
+	spanHashed
+)
+
+// span is a region of the plain text of a line, from start to end excluded
+type span struct {
+	start int
+	end   int
+	kind  spanKind
+
+	// level is the level of a spanLevel badge
+	level parser.LogLevel
+}
+
+var (
+	// Keywords highlighted in lines without a level, matched as whole words in any case
+	errorKeywords = []string{"error", "exception", "failed", "failure", "panic", "fatal"}
+	warnKeywords  = []string{"warning", "warn", "deprecated", "obsolete"}
+
+	// keywords maps every keyword to its kind, built once from the lists above
+	keywords = make(map[string]spanKind)
+
+	// maxKeywordLength bounds the words worth looking up in keywords
+	maxKeywordLength int
+)
+
+func init() {
+	for _, keyword := range errorKeywords {
+		keywords[keyword] = spanErrorKeyword
+		maxKeywordLength = max(maxKeywordLength, len(keyword))
+	}
+	for _, keyword := range warnKeywords {
+		keywords[keyword] = spanWarnKeyword
+		maxKeywordLength = max(maxKeywordLength, len(keyword))
+	}
+}
+
+// spanColor returns the color of a span covering text, nil when it is left uncolored
+func spanColor(s span, text string) *color.Color {
+	switch s.kind {
+	case spanURL:
+		return urlColor
+	case spanIP:
+		return ipColor
+	case spanErrorKeyword:
+		return errorKeywordColor
+	case spanWarnKeyword:
+		return warnKeywordColor
+	case spanNumber:
+		return numberColor
+	case spanDuration:
+		return durationColor
+	case spanUUID:
+		return uuidColor
+	case spanKey:
+		return keyColor
+	case spanValue:
+		return valueColor
+	case spanTimestamp:
+		return timestampColor
+	case spanSource:
+		return sourceColor
+	case spanLevel:
+		return levelColor(s.level)
+	case spanHashed:
+		return hashColor(text)
+	default:
+		return quotedColor
+	}
+}
+
+// findSpans scans a line once and returns the spans of its URLs, IP addresses,
+// keywords, numbers, durations, UUIDs, keys of key=value pairs and quoted
+// strings, in order. Overlaps are resolved as the scan goes: the leftmost
+// token wins and swallows anything inside it, so that a number within a URL
+// or a quoted string is not highlighted on its own.
+func findSpans(line string) []span {
+	return lineSpans(line, nil, true)
+}
+
+// lineSpans returns the spans of line: parts, which are already known and
+// sorted, the matches of the hashing patterns, and the tokens found between them
+func lineSpans(line string, parts []span, keywords bool) []span {
+	if len(hashing.Patterns) > 0 {
+		parts = append(parts, patternSpans(line, parts)...)
+		sort.Slice(parts, func(i, j int) bool { return parts[i].start < parts[j].start })
+	}
+
+	// Tokens are looked for between the parts, never across them
+	var spans []span
+	from := 0
+	for _, part := range parts {
+		spans = scanSpans(spans, line[:part.start], from, keywords)
+		spans = append(spans, part)
+		from = part.end
+	}
+	return scanSpans(spans, line, from, keywords)
+}
+
+// scanSpans appends the spans found in line[from:] to spans. Keywords are only
+// looked for when asked to, since they duplicate the level of leveled lines.
+func scanSpans(spans []span, line string, from int, keywords bool) []span {
+	keyless := from
+	for i := from; i < len(line); {
+		if i > from && isWordByte(line[i]) && isWordByte(line[i-1]) {
+			// Tokens only start at word boundaries
+			i++
+			continue
+		}
+
+		end, kind := tokenAt(line, i, keywords, &keyless)
+		if kind != 0 {
+			spans = append(spans, span{start: i, end: end, kind: kind})
+		}
+		i = end
+
+		if kind == spanKey {
+			// Skip the = sign: the value is a token of its own, or plain text
+			key := line[spans[len(spans)-1].start:end]
+			i++
+			end, kind = tokenAt(line, i, false, &keyless)
+			switch {
+			case hashKeys[key]:
+				if kind != spanQuoted {
+					end = valueEnd(line, i)
+				}
+				kind = spanHashed
+			case kind == 0 || kind == spanKey:
+				end, kind = valueEnd(line, i), spanValue
+			}
+			if end > i {
+				spans = append(spans, span{start: i, end: end, kind: kind})
+				i = end
+			}
+		}
+	}
+
+	return spans
+}
+
+// tokenAt returns the end and kind of the token starting at line[i]. When
+// there is none, it returns where the next one may start and a zero kind.
+// keyless is the end of the last run of key characters found without an =
+// sign after it: no key starts before it, so that long dotted or dashed runs
+// are not scanned again from each of their words.
+func tokenAt(line string, i int, keywords bool, keyless *int) (int, spanKind) {
+	c := line[i]
+
+	if c == '"' {
+		if end := quotedEnd(line, i); end > 0 {
+			return end, spanQuoted
+		}
+		return i + 1, 0
+	}
+
+	if !isWordByte(c) {
+		return i + 1, 0
+	}
+
+	// i starts a word
+	if c == 'h' && (strings.HasPrefix(line[i:], "http://") || strings.HasPrefix(line[i:], "https://")) {
+		end := i
+		for end < len(line) && !isSpace(line[end]) {
+			end++
+		}
+		return end, spanURL
+	}
+
+	if end := uuidEnd(line, i); end > 0 {
+		return end, spanUUID
+	}
+
+	if i >= *keyless {
+		end, ok := keyEnd(line, i)
+		if ok {
+			return end, spanKey
+		}
+		*keyless = end
+	}
+
+	if isDigit(c) {
+		if end := ipEnd(line, i); end > 0 {
+			return end, spanIP
+		}
+		if end := durationEnd(line, i); end > 0 {
+			return end, spanDuration
+		}
+	}
+
+	end := i
+	for end < len(line) && isWordByte(line[end]) {
+		end++
+	}
+	if kind := wordKind(line[i:end]); kind == spanNumber || (kind != 0 && keywords) {
+		return end, kind
+	}
+	return end, 0
+}
+
+// valueEnd returns the end of the plain value of a key=value pair starting at line[i]
+func valueEnd(line string, i int) int {
+	for i < len(line) && !isSpace(line[i]) && !strings.ContainsRune(",;)]}", rune(line[i])) {
+		i++
+	}
+	return i
+}
+
+// wordKind returns the kind of span of a whole word, or 0 when it is not highlighted
+func wordKind(word string) spanKind {
+	if isDigit(word[0]) {
+		for i := 1; i < len(word); i++ {
+			if !isDigit(word[i]) {
+				return 0
+			}
+		}
+		return spanNumber
+	}
+
+	if len(word) > maxKeywordLength {
+		return 0
+	}
+
+	// Lower-case into a stack buffer: the lookup then does not allocate
+	var buf [16]byte
+	for i := 0; i < len(word); i++ {
+		c := word[i]
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		buf[i] = c
+	}
+	return keywords[string(buf[:len(word)])]
+}
+
+// ipEnd returns the end of the IPv4 address starting at line[i], or 0
+func ipEnd(line string, i int) int {
+	for group := 0; group < 4; group++ {
+		if group > 0 {
+			if i >= len(line) || line[i] != '.' {
+				return 0
+			}
+			i++
+		}
+
+		n := 0
+		for i < len(line) && isDigit(line[i]) && n < 3 {
+			i++
+			n++
+		}
+		if n == 0 {
+			return 0
+		}
+	}
+
+	if i < len(line) && isWordByte(line[i]) {
+		return 0
+	}
+	return i
+}
+
+// uuidEnd returns the end of the UUID starting at line[i], or 0
+func uuidEnd(line string, i int) int {
+	const layout = "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"
+	if i+len(layout) > len(line) {
+		return 0
+	}
+	for j := 0; j < len(layout); j++ {
+		c := line[i+j]
+		if layout[j] == '-' {
+			if c != '-' {
+				return 0
+			}
+		} else if !isHexDigit(c) {
+			return 0
+		}
+	}
+
+	end := i + len(layout)
+	if end < len(line) && (isWordByte(line[end]) || line[end] == '-') {
+		return 0
+	}
+	return end
+}
+
+// keyEnd returns the end of the run of key characters starting at line[i]:
+// word characters, dots and dashes. ok reports whether the run is the key of
+// a key=value pair.
+func keyEnd(line string, i int) (end int, ok bool) {
+	end = i
+	for end < len(line) && (isWordByte(line[end]) || line[end] == '.' || line[end] == '-') {
+		end++
+	}
+	return end, end < len(line) && line[end] == '=' && end+1 < len(line) && !isSpace(line[end+1])
+}
+
+// durationUnits are the units of Go durations, longest first so that ms is
+// not read as m
+var durationUnits = []string{"ns", "us", "µs", "ms", "s", "m", "h"}
+
+// durationEnd returns the end of the Go-style duration starting at line[i],
+// like 15ms, 1.5s or 2h45m, or 0
+func durationEnd(line string, i int) int {
+	units := 0
+	for i < len(line) && isDigit(line[i]) {
+		for i < len(line) && isDigit(line[i]) {
+			i++
+		}
+		if i+1 < len(line) && line[i] == '.' && isDigit(line[i+1]) {
+			i++
+			for i < len(line) && isDigit(line[i]) {
+				i++
+			}
+		}
+
+		unit := ""
+		for _, u := range durationUnits {
+			if strings.HasPrefix(line[i:], u) {
+				unit = u
+				break
+			}
+		}
+		if unit == "" {
+			return 0
+		}
+		i += len(unit)
+		units++
+	}
+
+	if units == 0 || (i < len(line) && isWordByte(line[i])) {
+		return 0
+	}
+	return i
+}
+
+// quotedEnd returns the end of the double-quoted string opening at line[i],
+// or 0 when it is not closed
+func quotedEnd(line string, i int) int {
+	for j := i + 1; j < len(line); j++ {
+		switch line[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1
+		}
+	}
+	return 0
+}
+
+// render writes line with every span colored, in a single pass
+func render(line string, spans []span) string {
+	if len(spans) == 0 {
+		return line
+	}
+
+	var b strings.Builder
+	b.Grow(len(line) + 16*len(spans))
+
+	last := 0
+	for _, s := range spans {
+		b.WriteString(line[last:s.start])
+		if c := spanColor(s, line[s.start:s.end]); c != nil {
+			b.WriteString(c.Sprint(line[s.start:s.end]))
+		} else {
+			b.WriteString(line[s.start:s.end])
+		}
+		last = s.end
+	}
+	b.WriteString(line[last:])
+
+	return b.String()
+}
+
+// isWordByte reports whether c is part of a word as matched by \w
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || isDigit(c) || c == '_'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isSpace reports whether c is a space as matched by \s
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\f' || c == '\r'
+}
@@ -0,0 +1,190 @@
+package colorizer
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"logtail/internal/parser"
+)
+
+// ansiPattern matches the escape sequences inserted by the colorizer
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+func TestFindSpans(t *testing.T) {
+	// spanText is a span as the text it covers, for readable expectations
+	type spanText struct {
+		text string
+		kind spanKind
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []spanText
+	}{
+		{
+			name:  "URL swallows the numbers it contains",
+			input: "GET https://example.com:8443/v1/users/42 took 15 ms",
+			want: []spanText{
+				{"https://example.com:8443/v1/users/42", spanURL},
+				{"15", spanNumber},
+			},
+		},
+		{
+			name:  "IP address rather than four numbers",
+			input: "Request from 192.168.1.100 denied",
+			want:  []spanText{{"192.168.1.100", spanIP}},
+		},
+		{
+			name:  "Keywords as whole words in any case",
+			input: "FAILED: deprecated call raised an Exception, errors ignored",
+			want: []spanText{
+				{"FAILED", spanErrorKeyword},
+				{"deprecated", spanWarnKeyword},
+				{"Exception", spanErrorKeyword},
+			},
+		},
+		{
+			name:  "Quoted string with escaped quote",
+			input: `user "bob \"the builder\" 2" failed 3 times`,
+			want: []spanText{
+				{`"bob \"the builder\" 2"`, spanQuoted},
+				{"failed", spanErrorKeyword},
+				{"3", spanNumber},
+			},
+		},
+		{
+			name:  "Digits inside words are not numbers",
+			input: "host web01 returned 5xx and v2 after 300",
+			want:  []spanText{{"300", spanNumber}},
+		},
+		{
+			name:  "Durations, UUIDs and key=value pairs",
+			input: "job=3f2b8c1e-9a4d-4e6f-b2c1-0d9e8f7a6b5c took 1.5s, retry in 2m30s status=503",
+			want: []spanText{
+				{"job", spanKey},
+				{"3f2b8c1e-9a4d-4e6f-b2c1-0d9e8f7a6b5c", spanUUID},
+				{"1.5s", spanDuration},
+				{"2m30s", spanDuration},
+				{"status", spanKey},
+				{"503", spanNumber},
+			},
+		},
+		{
+			name:  "Plain values",
+			input: "path=/var/log/app.log user=bob, ok",
+			want: []spanText{
+				{"path", spanKey},
+				{"/var/log/app.log", spanValue},
+				{"user", spanKey},
+				{"bob", spanValue},
+			},
+		},
+		{
+			name:  "Unclosed quote",
+			input: `message "never closed 7`,
+			want:  []spanText{{"7", spanNumber}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []spanText
+			for _, s := range findSpans(tt.input) {
+				got = append(got, spanText{tt.input[s.start:s.end], s.kind})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findSpans() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindSpansLongTokens(t *testing.T) {
+	// Each word of a dotted or dashed run used to scan the rest of the run
+	// again for an = sign
+	for _, input := range []string{
+		strings.Repeat("a-", 100_000) + "a",
+		strings.Repeat("a.", 100_000) + "a",
+		strings.Repeat("a.", 100_000) + "a=1",
+	} {
+		start := time.Now()
+		spans := findSpans(input)
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("findSpans() on a %d-byte token took %v", len(input), elapsed)
+		}
+		if strings.HasSuffix(input, "=1") && (len(spans) == 0 || spans[0].kind != spanKey || spans[0].end != len(input)-2) {
+			t.Errorf("findSpans() on a long key = %v, want the key first", spans[:min(len(spans), 1)])
+		}
+	}
+}
+
+func TestColorizeSpecialPatternsEscapes(t *testing.T) {
+	originalEnabled := Enabled()
+	SetEnabled(true)
+	defer SetEnabled(originalEnabled)
+
+	// Numbers and keywords used to be colored again inside earlier escapes
+	input := `ERROR: warning 31 from 10.0.0.1 at https://example.com/error/1 "panic 0"`
+	result := colorizeSpecialPatterns(input)
+
+	if result == input {
+		t.Fatal("colorizeSpecialPatterns() did not color anything")
+	}
+	if plain := ansiPattern.ReplaceAllString(result, ""); plain != input {
+		t.Errorf("colorizeSpecialPatterns() changed the text: got %q, want %q", plain, input)
+	}
+	if escapes := len(ansiPattern.FindAllString(result, -1)); escapes != 2*len(findSpans(input)) {
+		t.Errorf("colorizeSpecialPatterns() wrote %d escapes, want one pair per span", escapes)
+	}
+}
+
+func TestEntrySpans(t *testing.T) {
+	line := `2024-09-30 10:30:45 ERROR [api] request id=42 from 10.0.0.1 failed after 250ms: "upstream error"`
+	entry := parser.ParseLogLine(line)
+
+	type spanText struct {
+		text string
+		kind spanKind
+	}
+	want := []spanText{
+		{"2024-09-30 10:30:45", spanTimestamp},
+		{"ERROR", spanLevel},
+		{"[api]", spanSource},
+		{"id", spanKey},
+		{"42", spanNumber},
+		{"10.0.0.1", spanIP},
+		{"250ms", spanDuration},
+		{`"upstream error"`, spanQuoted},
+	}
+
+	// Keywords such as "failed" are left to the level badge
+	var got []spanText
+	for _, s := range entrySpans(entry, line) {
+		got = append(got, spanText{line[s.start:s.end], s.kind})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("entrySpans() = %v, want %v", got, want)
+	}
+}
+
+func TestColorizeLogLineBadge(t *testing.T) {
+	originalEnabled := Enabled()
+	SetEnabled(true)
+	defer SetEnabled(originalEnabled)
+
+	line := "2024-09-30 10:30:45 ERROR Database connection failed"
+	result := ColorizeLogLine(parser.ParseLogLine(line), line)
+
+	if plain := ansiPattern.ReplaceAllString(result, ""); plain != line {
+		t.Errorf("ColorizeLogLine() changed the text: got %q, want %q", plain, line)
+	}
+
+	// Only the badge takes the level color, not the message
+	if badge := errorColor.Sprint("ERROR"); !regexp.MustCompile(regexp.QuoteMeta(badge) + ` Database connection failed$`).MatchString(result) {
+		t.Errorf("ColorizeLogLine() = %q, want the level color on the badge only", result)
+	}
+}
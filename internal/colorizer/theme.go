@@ -0,0 +1,485 @@
+package colorizer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Depth is the number of colors a terminal can show
+type Depth int
+
+const (
+	Depth16 Depth = iota
+	Depth256
+	DepthTrueColor
+)
+
+// DetectDepth guesses the color depth of the terminal from the COLORTERM and
+// TERM environment variables, read through getenv
+func DetectDepth(getenv func(string) string) Depth {
+	switch strings.ToLower(getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return DepthTrueColor
+	}
+
+	term := getenv("TERM")
+	switch {
+	case strings.HasSuffix(term, "-direct"):
+		return DepthTrueColor
+	case strings.Contains(term, "256color"):
+		return Depth256
+	default:
+		return Depth16
+	}
+}
+
+// Elements are the names of the parts of a line a theme can style
+var Elements = []string{
+	"level.fatal",
+	"level.critical",
+	"level.error",
+	"level.warn",
+	"level.notice",
+	"level.info",
+	"level.debug",
+	"level.trace",
+	"timestamp",
+	"source",
+	"url",
+	"ip",
+	"key",
+	"value",
+	"number",
+	"string",
+	"duration",
+	"uuid",
+	"keyword.error",
+	"keyword.warn",
+}
+
+// Theme maps element names to styles. A style is a space-separated list of
+// attributes (bold, faint, italic, underline), a foreground color and
+// optionally "on" and a background color. Colors are names (red,
+// bright-red...), 256-color palette indexes or #rrggbb truecolor values, as in
+// "bold #ff8700 on 236". An empty style leaves the element uncolored.
+type Theme map[string]string
+
+// Themes are the built-in themes
+var Themes = map[string]Theme{
+	"dark": {
+		"level.fatal":    "bold red",
+		"level.critical": "bold bright-red",
+		"level.error":    "bold red",
+		"level.warn":     "bold yellow",
+		"level.notice":   "bold cyan",
+		"level.info":     "cyan",
+		"level.debug":    "magenta",
+		"level.trace":    "magenta",
+		"timestamp":      "blue",
+		"source":         "green",
+		"url":            "underline blue",
+		"ip":             "cyan",
+		"key":            "bright-cyan",
+		"value":          "",
+		"number":         "bright-magenta",
+		"string":         "bright-green",
+		"duration":       "bright-yellow",
+		"uuid":           "bright-blue",
+		"keyword.error":  "bold red",
+		"keyword.warn":   "bold yellow",
+	},
+	"light": {
+		"level.fatal":    "bold #af0000",
+		"level.critical": "bold #af0000",
+		"level.error":    "bold #d70000",
+		"level.warn":     "bold #af5f00",
+		"level.notice":   "bold #005f87",
+		"level.info":     "#005f87",
+		"level.debug":    "#5f5f87",
+		"level.trace":    "#808080",
+		"timestamp":      "#0000af",
+		"source":         "#005f00",
+		"url":            "underline #0000d7",
+		"ip":             "#008787",
+		"key":            "#5f0087",
+		"value":          "#303030",
+		"number":         "#870087",
+		"string":         "#008700",
+		"duration":       "#875f00",
+		"uuid":           "#5f5faf",
+		"keyword.error":  "bold #d70000",
+		"keyword.warn":   "bold #af5f00",
+	},
+	"solarized": {
+		"level.fatal":    "bold #d33682",
+		"level.critical": "bold #cb4b16",
+		"level.error":    "bold #dc322f",
+		"level.warn":     "bold #b58900",
+		"level.notice":   "bold #268bd2",
+		"level.info":     "#268bd2",
+		"level.debug":    "#6c71c4",
+		"level.trace":    "#586e75",
+		"timestamp":      "#586e75",
+		"source":         "#859900",
+		"url":            "underline #268bd2",
+		"ip":             "#2aa198",
+		"key":            "#6c71c4",
+		"value":          "#93a1a1",
+		"number":         "#d33682",
+		"string":         "#2aa198",
+		"duration":       "#cb4b16",
+		"uuid":           "#6c71c4",
+		"keyword.error":  "bold #dc322f",
+		"keyword.warn":   "bold #b58900",
+	},
+	"high-contrast": {
+		"level.fatal":    "bold bright-white on red",
+		"level.critical": "bold underline bright-red",
+		"level.error":    "bold bright-red",
+		"level.warn":     "bold bright-yellow",
+		"level.notice":   "bold bright-white",
+		"level.info":     "bold bright-cyan",
+		"level.debug":    "bright-magenta",
+		"level.trace":    "white",
+		"timestamp":      "bold bright-blue",
+		"source":         "bold bright-green",
+		"url":            "bold underline bright-blue",
+		"ip":             "bright-cyan",
+		"key":            "bold bright-white",
+		"value":          "bright-white",
+		"number":         "bright-magenta",
+		"string":         "bright-green",
+		"duration":       "bright-yellow",
+		"uuid":           "bright-blue",
+		"keyword.error":  "bold bright-white on red",
+		"keyword.warn":   "bold black on yellow",
+	},
+}
+
+// DefaultTheme is the theme used unless another is selected
+const DefaultTheme = "dark"
+
+// ThemeNames returns the names of the built-in themes, sorted
+func ThemeNames() []string {
+	names := make([]string, 0, len(Themes))
+	for name := range Themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Extend returns a copy of the theme with the styles of overrides replacing its own
+func (t Theme) Extend(overrides Theme) Theme {
+	extended := make(Theme, len(t)+len(overrides))
+	for element, style := range t {
+		extended[element] = style
+	}
+	for element, style := range overrides {
+		extended[element] = style
+	}
+	return extended
+}
+
+// SetTheme makes theme the colors of every line colorized from now on,
+// downgrading them to what a terminal of the given depth can show. Elements
+// missing from the theme keep the style of the default theme.
+func SetTheme(theme Theme, depth Depth) error {
+	for element := range theme {
+		if !isElement(element) {
+			return fmt.Errorf("unknown theme element %q", element)
+		}
+	}
+
+	colors := make(map[string]*color.Color, len(Elements))
+	for _, element := range Elements {
+		spec, ok := theme[element]
+		if !ok {
+			spec = Themes[DefaultTheme][element]
+		}
+
+		style, err := ParseStyle(spec)
+		if err != nil {
+			return fmt.Errorf("theme element %s: %v", element, err)
+		}
+		colors[element] = style.color(depth)
+	}
+
+	fatalColor = colors["level.fatal"]
+	criticalColor = colors["level.critical"]
+	errorColor = colors["level.error"]
+	warnColor = colors["level.warn"]
+	noticeColor = colors["level.notice"]
+	infoColor = colors["level.info"]
+	debugColor = colors["level.debug"]
+	traceColor = colors["level.trace"]
+	timestampColor = colors["timestamp"]
+	sourceColor = colors["source"]
+	urlColor = colors["url"]
+	ipColor = colors["ip"]
+	keyColor = colors["key"]
+	valueColor = colors["value"]
+	numberColor = colors["number"]
+	quotedColor = colors["string"]
+	durationColor = colors["duration"]
+	uuidColor = colors["uuid"]
+	errorKeywordColor = colors["keyword.error"]
+	warnKeywordColor = colors["keyword.warn"]
+	setHashPalette(depth)
+	return nil
+}
+
+func isElement(name string) bool {
+	for _, element := range Elements {
+		if element == name {
+			return true
+		}
+	}
+	return false
+}
+
+// colorKind tells how a Color is defined
+type colorKind uint8
+
+const (
+	colorDefault colorKind = iota
+	// colorBasic is one of the 16 colors, 8 normal and 8 bright
+	colorBasic
+	// colorIndexed is an entry of the 256-color palette
+	colorIndexed
+	colorRGB
+)
+
+// Color is a foreground or background color of a Style
+type Color struct {
+	kind    colorKind
+	index   uint8
+	r, g, b uint8
+}
+
+// Style is a parsed theme style
+type Style struct {
+	Fg, Bg    Color
+	Bold      bool
+	Faint     bool
+	Italic    bool
+	Underline bool
+}
+
+// basicNames are the names of the 8 normal colors, in ANSI order
+var basicNames = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// ParseStyle parses a style as written in themes
+func ParseStyle(spec string) (Style, error) {
+	var style Style
+	background := false
+
+	for _, word := range strings.Fields(strings.ToLower(spec)) {
+		switch word {
+		case "bold":
+			style.Bold = true
+		case "faint", "dim":
+			style.Faint = true
+		case "italic":
+			style.Italic = true
+		case "underline":
+			style.Underline = true
+		case "on":
+			if background {
+				return Style{}, fmt.Errorf("invalid style %q: more than one background", spec)
+			}
+			background = true
+		default:
+			c, err := parseColor(word)
+			if err != nil {
+				return Style{}, fmt.Errorf("invalid style %q: %v", spec, err)
+			}
+			if background {
+				style.Bg = c
+			} else {
+				style.Fg = c
+			}
+		}
+	}
+
+	return style, nil
+}
+
+// parseColor parses a color name, palette index or #rrggbb value
+func parseColor(word string) (Color, error) {
+	if strings.HasPrefix(word, "#") {
+		value, err := strconv.ParseUint(word[1:], 16, 32)
+		if err != nil || len(word) != 7 {
+			return Color{}, fmt.Errorf("invalid hex color %s", word)
+		}
+		return Color{kind: colorRGB, r: uint8(value >> 16), g: uint8(value >> 8), b: uint8(value)}, nil
+	}
+
+	if index, err := strconv.Atoi(word); err == nil {
+		if index < 0 || index > 255 {
+			return Color{}, fmt.Errorf("color index %d out of range 0-255", index)
+		}
+		return Color{kind: colorIndexed, index: uint8(index)}, nil
+	}
+
+	name, bright := strings.CutPrefix(word, "bright-")
+	for i, basic := range basicNames {
+		if name == basic {
+			if bright {
+				i += 8
+			}
+			return Color{kind: colorBasic, index: uint8(i)}, nil
+		}
+	}
+	return Color{}, fmt.Errorf("unknown color %s", word)
+}
+
+// color returns the fatih color of a style on a terminal of the given depth,
+// or nil when the style leaves text uncolored
+func (s Style) color(depth Depth) *color.Color {
+	var attributes []color.Attribute
+	if s.Bold {
+		attributes = append(attributes, color.Bold)
+	}
+	if s.Faint {
+		attributes = append(attributes, color.Faint)
+	}
+	if s.Italic {
+		attributes = append(attributes, color.Italic)
+	}
+	if s.Underline {
+		attributes = append(attributes, color.Underline)
+	}
+	attributes = append(attributes, s.Fg.attributes(depth, false)...)
+	attributes = append(attributes, s.Bg.attributes(depth, true)...)
+
+	if len(attributes) == 0 {
+		return nil
+	}
+
+	// Whether to color is decided by the colorizer, not by fatih/color
+	c := color.New(attributes...)
+	c.EnableColor()
+	return c
+}
+
+// attributes returns the SGR parameters selecting a color, downgraded to
+// the depth of the terminal
+func (c Color) attributes(depth Depth, background bool) []color.Attribute {
+	base := color.Attribute(38)
+	if background {
+		base = 48
+	}
+
+	switch {
+	case c.kind == colorDefault:
+		return nil
+	case c.kind == colorRGB && depth == DepthTrueColor:
+		return []color.Attribute{base, 2, color.Attribute(c.r), color.Attribute(c.g), color.Attribute(c.b)}
+	case c.kind == colorRGB && depth == Depth256:
+		return []color.Attribute{base, 5, color.Attribute(rgbToIndex(c.r, c.g, c.b))}
+	case c.kind == colorIndexed && depth >= Depth256:
+		return []color.Attribute{base, 5, color.Attribute(c.index)}
+	}
+
+	// Down to the 16 basic colors
+	index := c.index
+	switch c.kind {
+	case colorRGB:
+		index = nearestBasic(c.r, c.g, c.b)
+	case colorIndexed:
+		if index >= 16 {
+			r, g, b := indexToRGB(index)
+			index = nearestBasic(r, g, b)
+		}
+	}
+
+	code := color.Attribute(30)
+	if index >= 8 {
+		code = 90
+		index -= 8
+	}
+	if background {
+		code += 10
+	}
+	return []color.Attribute{code + color.Attribute(index)}
+}
+
+// cubeLevels are the component values of the 6x6x6 cube of the 256-color palette
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// basicRGB are the usual values of the 16 basic colors, as in xterm
+var basicRGB = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// rgbToIndex returns the closest color of the 256-color palette, from its
+// color cube or its grayscale ramp
+func rgbToIndex(r, g, b uint8) uint8 {
+	cube := func(v uint8) int {
+		best := 0
+		for i, level := range cubeLevels {
+			if abs(int(v)-level) < abs(int(v)-cubeLevels[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+	ri, gi, bi := cube(r), cube(g), cube(b)
+	cubeIndex := uint8(16 + 36*ri + 6*gi + bi)
+	cubeDistance := distance(int(r), int(g), int(b), cubeLevels[ri], cubeLevels[gi], cubeLevels[bi])
+
+	// The ramp goes from 8 to 238 by steps of 10
+	gray := (int(r) + int(g) + int(b)) / 3
+	step := min(max((gray-8+5)/10, 0), 23)
+	level := 8 + 10*step
+	if distance(int(r), int(g), int(b), level, level, level) < cubeDistance {
+		return uint8(232 + step)
+	}
+	return cubeIndex
+}
+
+// indexToRGB returns the color of an entry of the 256-color palette
+func indexToRGB(index uint8) (uint8, uint8, uint8) {
+	switch {
+	case index < 16:
+		c := basicRGB[index]
+		return uint8(c[0]), uint8(c[1]), uint8(c[2])
+	case index >= 232:
+		level := uint8(8 + 10*(int(index)-232))
+		return level, level, level
+	default:
+		i := int(index) - 16
+		return uint8(cubeLevels[i/36]), uint8(cubeLevels[i/6%6]), uint8(cubeLevels[i%6])
+	}
+}
+
+// nearestBasic returns the basic color closest to an RGB value
+func nearestBasic(r, g, b uint8) uint8 {
+	best, bestDistance := 0, -1
+	for i, c := range basicRGB {
+		if d := distance(int(r), int(g), int(b), c[0], c[1], c[2]); bestDistance < 0 || d < bestDistance {
+			best, bestDistance = i, d
+		}
+	}
+	return uint8(best)
+}
+
+// distance is the squared distance between two RGB colors
+func distance(r1, g1, b1, r2, g2, b2 int) int {
+	return (r1-r2)*(r1-r2) + (g1-g2)*(g1-g2) + (b1-b2)*(b1-b2)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
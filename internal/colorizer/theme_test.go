@@ -0,0 +1,102 @@
+package colorizer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestDetectDepth(t *testing.T) {
+	tests := []struct {
+		name      string
+		colorterm string
+		term      string
+		want      Depth
+	}{
+		{name: "Truecolor", colorterm: "truecolor", term: "xterm-256color", want: DepthTrueColor},
+		{name: "24bit", colorterm: "24bit", want: DepthTrueColor},
+		{name: "Direct color terminfo", term: "xterm-direct", want: DepthTrueColor},
+		{name: "256 colors", term: "screen-256color", want: Depth256},
+		{name: "Basic terminal", term: "xterm", want: Depth16},
+		{name: "Nothing set", want: Depth16},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := map[string]string{"COLORTERM": tt.colorterm, "TERM": tt.term}
+			if got := DetectDepth(func(key string) string { return env[key] }); got != tt.want {
+				t.Errorf("DetectDepth() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStyleDowngrade(t *testing.T) {
+	tests := []struct {
+		name  string
+		spec  string
+		depth Depth
+		want  []color.Attribute
+	}{
+		{name: "Truecolor kept", spec: "bold #ff8700", depth: DepthTrueColor, want: []color.Attribute{color.Bold, 38, 2, 255, 135, 0}},
+		{name: "Truecolor to 256", spec: "#ff8700", depth: Depth256, want: []color.Attribute{38, 5, 208}},
+		{name: "Truecolor gray to 256", spec: "#303030", depth: Depth256, want: []color.Attribute{38, 5, 236}},
+		{name: "Truecolor to 16", spec: "#d70000", depth: Depth16, want: []color.Attribute{color.FgRed}},
+		{name: "256 to 16", spec: "underline 21", depth: Depth16, want: []color.Attribute{color.Underline, color.FgBlue}},
+		{name: "Basic colors", spec: "bright-white on red", depth: DepthTrueColor, want: []color.Attribute{color.FgHiWhite, color.BgRed}},
+		{name: "256 background", spec: "on 236", depth: Depth256, want: []color.Attribute{48, 5, 236}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			style, err := ParseStyle(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseStyle() unexpected error: %v", err)
+			}
+			if got := style.color(tt.depth); !got.Equals(color.New(tt.want...)) {
+				t.Errorf("style %q at depth %v does not match attributes %v", tt.spec, tt.depth, tt.want)
+			}
+		})
+	}
+
+	if style, _ := ParseStyle(""); style.color(DepthTrueColor) != nil {
+		t.Error("empty style should leave text uncolored")
+	}
+}
+
+func TestParseStyleErrors(t *testing.T) {
+	for _, spec := range []string{"#12345", "#gggggg", "256", "purple", "red on blue on green"} {
+		if _, err := ParseStyle(spec); err == nil {
+			t.Errorf("ParseStyle(%q) expected error but got none", spec)
+		}
+	}
+}
+
+func TestBuiltinThemes(t *testing.T) {
+	defer SetTheme(Themes[DefaultTheme], Depth16)
+
+	for _, name := range ThemeNames() {
+		theme := Themes[name]
+
+		var elements []string
+		for _, element := range Elements {
+			if _, ok := theme[element]; ok {
+				elements = append(elements, element)
+			}
+		}
+		if !reflect.DeepEqual(elements, Elements) {
+			t.Errorf("theme %s styles %v, want every element", name, elements)
+		}
+
+		for _, depth := range []Depth{Depth16, Depth256, DepthTrueColor} {
+			if err := SetTheme(theme, depth); err != nil {
+				t.Errorf("SetTheme(%s) unexpected error: %v", name, err)
+			}
+		}
+	}
+
+	if err := SetTheme(Theme{"level.eror": "red"}, Depth16); err == nil {
+		t.Error("SetTheme() expected error for an unknown element")
+	}
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Config is the content of the logtail configuration file
+type Config struct {
+	// Theme selects a built-in or user theme, unless --theme is given
+	Theme string `json:"theme"`
+	// Themes defines user themes by name
+	Themes map[string]Theme `json:"themes"`
+
+	// HashColors and HashPatterns select values colored by hash, added to
+	// those of --hash-colors and --hash-pattern
+	HashColors   []string `json:"hash_colors"`
+	HashPatterns []string `json:"hash_patterns"`
+
+	// Levels maps level names, or values of numeric level fields, to the
+	// levels they stand for, as in "SUCCESS": "info" or "35": "warn"
+	Levels map[string]string `json:"levels"`
+}
+
+// Theme is a user theme: the styles of some elements, the other elements
+// keeping those of the theme it extends
+type Theme struct {
+	// Extends names the theme to start from, the default theme when empty
+	Extends string `json:"extends"`
+	// Styles maps element names, such as level.error or url, to styles such
+	// as "bold #d70000" or "245"
+	Styles map[string]string `json:"styles"`
+}
+
+// DefaultPath returns the path of the configuration file when --config is
+// not given, or "" when there is no user configuration directory
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "logtail", "config.json")
+}
+
+// Load reads the configuration file at path. Unknown settings are rejected,
+// so that a misspelled one is not silently ignored.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	cfg := &Config{}
+	if err := decoder.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	content := `{
+  "theme": "paper",
+  "themes": {
+    "paper": {
+      "extends": "light",
+      "styles": {"level.error": "bold #d70000", "url": "underline 26"}
+    }
+  }
+}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create config file: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+
+	if cfg.Theme != "paper" {
+		t.Errorf("Load() theme = %q, want %q", cfg.Theme, "paper")
+	}
+	paper := cfg.Themes["paper"]
+	if paper.Extends != "light" || paper.Styles["url"] != "underline 26" {
+		t.Errorf("Load() paper theme = %+v", paper)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := Load(filepath.Join(dir, "missing.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Load() of a missing file error = %v, want os.ErrNotExist", err)
+	}
+
+	misspelled := filepath.Join(dir, "misspelled.json")
+	os.WriteFile(misspelled, []byte(`{"theem": "dark"}`), 0644)
+	if _, err := Load(misspelled); err == nil || !strings.Contains(err.Error(), "theem") {
+		t.Errorf("Load() of an unknown setting error = %v, want it named", err)
+	}
+}
@@ -0,0 +1,174 @@
+package index
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"logtail/internal/input"
+	"logtail/internal/parser"
+)
+
+// DefaultInterval is the default number of bytes between two samples
+const DefaultInterval = 1 << 20
+
+// indexHeader identifies index files and their format version
+const indexHeader = "logtail-index v2"
+
+// Sample locates one line of the indexed file
+type Sample struct {
+	Offset int64
+	Line   int
+	// Time is the timestamp of the line, zero when it has none
+	Time time.Time
+}
+
+// Index is a sparse list of samples taken roughly every Interval bytes. It
+// lets time-range and line-number queries start reading near their target
+// instead of at the beginning of the file.
+type Index struct {
+	// Size and Inode describe the file when it was indexed, so that a stale
+	// index is not used after the file was rotated or truncated
+	Size     int64
+	Inode    uint64
+	Interval int64
+	// Zone names the zone timestamps written without one were read in, so
+	// that queries assuming another zone do not use the index
+	Zone    string
+	Samples []Sample
+}
+
+// Path returns the sidecar index path of a log file
+func Path(logPath string) string {
+	return logPath + ".idx"
+}
+
+// Build reads a log stream and samples the first timestamped line after every
+// interval bytes. The first line is always sampled.
+func Build(r io.Reader, interval int64) (*Index, error) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	idx := &Index{Interval: interval, Zone: parser.NaiveZone().String()}
+	lines := input.NewLineReader(r, 0)
+	lineParser := parser.NewParser()
+	next := int64(0)
+	lineNum := 1
+
+	for {
+		offset := lines.Offset()
+		line, err := lines.ReadLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if offset >= next {
+			timestamp := lineParser.Parse(line).Timestamp
+
+			// Prefer timestamped lines, but never leave the first line unsampled
+			if !timestamp.IsZero() || offset == 0 {
+				idx.Samples = append(idx.Samples, Sample{Offset: offset, Line: lineNum, Time: timestamp})
+				next = offset + interval
+			}
+		}
+
+		lineNum++
+	}
+
+	idx.Size = lines.Offset()
+	return idx, nil
+}
+
+// SeekTime returns the last sample known to be strictly before t. Reading from
+// there finds every line at or after t, provided the log is sorted.
+func (idx *Index) SeekTime(t time.Time) Sample {
+	best := Sample{Line: 1}
+	for _, sample := range idx.Samples {
+		if sample.Time.IsZero() {
+			continue
+		}
+		if !sample.Time.Before(t) {
+			break
+		}
+		best = sample
+	}
+	return best
+}
+
+// SeekLine returns the last sample at or before line n
+func (idx *Index) SeekLine(n int) Sample {
+	best := Sample{Line: 1}
+	for _, sample := range idx.Samples {
+		if sample.Line > n {
+			break
+		}
+		best = sample
+	}
+	return best
+}
+
+// Save writes the index to path as text: a header, ending with the zone,
+// followed by one "offset line unix-nanos" sample per line
+func (idx *Index) Save(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(file)
+	fmt.Fprintf(w, "%s %d %d %d %s\n", indexHeader, idx.Size, idx.Inode, idx.Interval, idx.Zone)
+	for _, sample := range idx.Samples {
+		nanos := int64(0)
+		if !sample.Time.IsZero() {
+			nanos = sample.Time.UnixNano()
+		}
+		fmt.Fprintf(w, "%d %d %d\n", sample.Offset, sample.Line, nanos)
+	}
+
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Load reads an index written by Save
+func Load(path string) (*Index, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	idx := &Index{}
+
+	var v1, v2 string
+	if _, err := fmt.Fscanf(r, "%s %s %d %d %d %s\n", &v1, &v2, &idx.Size, &idx.Inode, &idx.Interval, &idx.Zone); err != nil || v1+" "+v2 != indexHeader {
+		return nil, fmt.Errorf("%s is not a logtail index", path)
+	}
+
+	for {
+		var sample Sample
+		var nanos int64
+		_, err := fmt.Fscanf(r, "%d %d %d\n", &sample.Offset, &sample.Line, &nanos)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("corrupt index %s: %v", path, err)
+		}
+		if nanos != 0 {
+			sample.Time = time.Unix(0, nanos).UTC()
+		}
+		idx.Samples = append(idx.Samples, sample)
+	}
+
+	return idx, nil
+}
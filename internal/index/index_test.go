@@ -0,0 +1,101 @@
+package index
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var baseTime = time.Date(2024, 9, 30, 10, 0, 0, 0, time.UTC)
+
+// sortedLog returns a log of n lines one second apart, with an untimestamped
+// continuation line after every tenth entry
+func sortedLog(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "%s INFO request %d handled\n", baseTime.Add(time.Duration(i)*time.Second).Format(time.RFC3339), i)
+		if i%10 == 0 {
+			b.WriteString("    at continuation line\n")
+		}
+	}
+	return b.String()
+}
+
+func TestBuild(t *testing.T) {
+	content := sortedLog(5000)
+
+	idx, err := Build(strings.NewReader(content), 16*1024)
+	if err != nil {
+		t.Fatalf("Build() unexpected error: %v", err)
+	}
+
+	if idx.Size != int64(len(content)) {
+		t.Errorf("Build() size = %d, want %d", idx.Size, len(content))
+	}
+	if len(idx.Samples) < 10 {
+		t.Fatalf("Build() expected about one sample per 16KB, got %d", len(idx.Samples))
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	for _, sample := range idx.Samples {
+		// Every sample must point at the start of the line it names
+		offset := 0
+		for _, line := range lines[:sample.Line-1] {
+			offset += len(line)
+		}
+		if int64(offset) != sample.Offset {
+			t.Fatalf("Sample %+v: line %d starts at offset %d", sample, sample.Line, offset)
+		}
+		if sample.Time.IsZero() {
+			t.Errorf("Sample %+v should be on a timestamped line", sample)
+		}
+	}
+}
+
+func TestSeek(t *testing.T) {
+	idx := &Index{Samples: []Sample{
+		{Offset: 0, Line: 1, Time: baseTime},
+		{Offset: 1000, Line: 20, Time: baseTime.Add(time.Minute)},
+		{Offset: 2000, Line: 40, Time: baseTime.Add(2 * time.Minute)},
+	}}
+
+	if got := idx.SeekTime(baseTime.Add(90 * time.Second)); got.Offset != 1000 {
+		t.Errorf("SeekTime() = %+v, want the sample at offset 1000", got)
+	}
+	if got := idx.SeekTime(baseTime.Add(time.Minute)); got.Offset != 0 {
+		t.Errorf("SeekTime() on an exact sample time = %+v, want the previous sample", got)
+	}
+	if got := idx.SeekTime(baseTime.Add(-time.Hour)); got.Offset != 0 || got.Line != 1 {
+		t.Errorf("SeekTime() before the first sample = %+v, want the start of file", got)
+	}
+	if got := idx.SeekLine(45); got.Line != 40 {
+		t.Errorf("SeekLine() = %+v, want the sample at line 40", got)
+	}
+	if got := idx.SeekLine(20); got.Line != 20 {
+		t.Errorf("SeekLine() on an exact sample line = %+v, want that sample", got)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	idx, err := Build(strings.NewReader("no timestamp\n"+sortedLog(2000)), 8*1024)
+	if err != nil {
+		t.Fatalf("Build() unexpected error: %v", err)
+	}
+	idx.Inode = 1234
+
+	path := Path(filepath.Join(t.TempDir(), "app.log"))
+	if err := idx.Save(path); err != nil {
+		t.Fatalf("Save() unexpected error: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, idx) {
+		t.Errorf("Load() = %+v, want %+v", loaded, idx)
+	}
+}
@@ -0,0 +1,87 @@
+package index
+
+import (
+	"bytes"
+	"io"
+	"time"
+
+	"logtail/internal/parser"
+)
+
+const (
+	// searchBlock is the range below which binary search hands over to a
+	// linear scan
+	searchBlock = 64 * 1024
+
+	// probeSize bounds how much is read to find a timestamp near an offset
+	probeSize = 64 * 1024
+)
+
+// SearchTime binary-searches a log sorted by time for a line start from which
+// reading finds every line at or after t. It returns 0 when the log does not
+// look sorted, since no region can then be skipped safely.
+func SearchTime(r io.ReaderAt, size int64, t time.Time) (int64, error) {
+	_, first, ok, err := timestampAfter(r, 0, size)
+	if err != nil || !ok {
+		return 0, err
+	}
+	_, last, ok, err := timestampAfter(r, max(0, size-probeSize), size)
+	if err != nil || !ok || last.Before(first) {
+		return 0, err
+	}
+
+	// Invariant: lo is the start of a line stamped before t, or 0
+	lo, hi := int64(0), size
+	for hi-lo > searchBlock {
+		mid := lo + (hi-lo)/2
+
+		start, timestamp, ok, err := timestampAfter(r, mid, hi)
+		if err != nil {
+			return 0, err
+		}
+
+		if ok && timestamp.Before(t) {
+			lo = start
+		} else {
+			hi = mid
+		}
+	}
+
+	return lo, nil
+}
+
+// timestampAfter returns the start and timestamp of the first timestamped line
+// beginning at or after from, and before limit. Offsets other than zero are
+// assumed to fall in the middle of a line, which is skipped.
+func timestampAfter(r io.ReaderAt, from, limit int64) (int64, time.Time, bool, error) {
+	buf := make([]byte, min(probeSize, limit-from))
+	n, err := r.ReadAt(buf, from)
+	if err != nil && err != io.EOF {
+		return 0, time.Time{}, false, err
+	}
+	buf = buf[:n]
+
+	pos := 0
+	if from > 0 {
+		newline := bytes.IndexByte(buf, '\n')
+		if newline < 0 {
+			return 0, time.Time{}, false, nil
+		}
+		pos = newline + 1
+	}
+
+	for pos < len(buf) {
+		end := bytes.IndexByte(buf[pos:], '\n')
+		if end < 0 {
+			// The line continues past the probe: its end cannot be trusted
+			break
+		}
+
+		if timestamp := parser.ParseLogLine(string(buf[pos : pos+end])).Timestamp; !timestamp.IsZero() {
+			return from + int64(pos), timestamp, true, nil
+		}
+		pos += end + 1
+	}
+
+	return 0, time.Time{}, false, nil
+}
@@ -0,0 +1,49 @@
+package index
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSearchTime(t *testing.T) {
+	content := sortedLog(20000)
+	reader := strings.NewReader(content)
+	size := int64(len(content))
+
+	for _, seconds := range []int{0, 1, 777, 10000, 19999, 30000} {
+		target := baseTime.Add(time.Duration(seconds) * time.Second)
+
+		offset, err := SearchTime(reader, size, target)
+		if err != nil {
+			t.Fatalf("SearchTime() unexpected error: %v", err)
+		}
+
+		// The offset must be a line start before the target...
+		if offset > 0 && content[offset-1] != '\n' {
+			t.Fatalf("SearchTime(%ds) = %d, not a line start", seconds, offset)
+		}
+		want := target.Format(time.RFC3339)
+		idx := strings.Index(content, want)
+		if idx >= 0 && int64(idx) < offset {
+			t.Errorf("SearchTime(%ds) = %d skips the target line at %d", seconds, offset, idx)
+		}
+
+		// ...and close enough to it to be worth it
+		if idx >= 0 && int64(idx)-offset > 2*searchBlock {
+			t.Errorf("SearchTime(%ds) = %d is too far before the target at %d", seconds, offset, idx)
+		}
+	}
+}
+
+func TestSearchTimeUnsorted(t *testing.T) {
+	content := "2024-09-30T12:00:00Z INFO late\n" + strings.Repeat("filler line\n", 20000) + "2024-09-30T10:00:00Z INFO early\n"
+
+	offset, err := SearchTime(strings.NewReader(content), int64(len(content)), time.Date(2024, 9, 30, 11, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("SearchTime() unexpected error: %v", err)
+	}
+	if offset != 0 {
+		t.Errorf("SearchTime() on an unsorted log = %d, want 0", offset)
+	}
+}
@@ -0,0 +1,165 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// Compression identifies the compression format of a log stream
+type Compression int
+
+const (
+	CompressionNone Compression = iota
+	CompressionGzip
+	CompressionBzip2
+	CompressionXz
+	CompressionZstd
+)
+
+// magicNumbers maps the leading bytes of a stream to its compression format
+var magicNumbers = []struct {
+	compression Compression
+	magic       []byte
+}{
+	{CompressionGzip, []byte{0x1f, 0x8b}},
+	{CompressionBzip2, []byte("BZh")},
+	{CompressionXz, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{CompressionZstd, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+}
+
+// headerSize is enough to tell every compression format apart from text
+const headerSize = 10
+
+// bzip2Magics follow the printable "BZh" signature of bzip2 streams and their
+// block size digit: the magic of the first block, or of the end of an empty
+// stream
+var bzip2Magics = [][]byte{
+	{0x31, 0x41, 0x59, 0x26, 0x53, 0x59},
+	{0x17, 0x72, 0x45, 0x38, 0x50, 0x90},
+}
+
+// String returns the conventional name of the compression format
+func (c Compression) String() string {
+	switch c {
+	case CompressionGzip:
+		return "gzip"
+	case CompressionBzip2:
+		return "bzip2"
+	case CompressionXz:
+		return "xz"
+	case CompressionZstd:
+		return "zstd"
+	default:
+		return "none"
+	}
+}
+
+// Detect reports the compression format announced by the leading bytes of a stream
+func Detect(header []byte) Compression {
+	for _, m := range magicNumbers {
+		if bytes.HasPrefix(header, m.magic) && (m.compression != CompressionBzip2 || isBzip2(header)) {
+			return m.compression
+		}
+	}
+	return CompressionNone
+}
+
+// isBzip2 reports whether a header starting with "BZh" is a bzip2 one, as
+// text may start with these letters too
+func isBzip2(header []byte) bool {
+	if len(header) < headerSize || header[3] < '1' || header[3] > '9' {
+		return false
+	}
+	for _, magic := range bzip2Magics {
+		if bytes.Equal(header[4:headerSize], magic) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewReader wraps r so that compressed content is transparently decompressed.
+// Uncompressed content is passed through unchanged.
+func NewReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+
+	// Peek may return fewer bytes than requested for short inputs, which is fine
+	header, err := br.Peek(headerSize)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	switch Detect(header) {
+	case CompressionGzip:
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip stream: %v", err)
+		}
+		return zr, nil
+	case CompressionBzip2:
+		return io.NopCloser(bzip2.NewReader(br)), nil
+	case CompressionXz:
+		xr, err := xz.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("invalid xz stream: %v", err)
+		}
+		return io.NopCloser(xr), nil
+	case CompressionZstd:
+		zr, err := zstd.NewReader(br, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, fmt.Errorf("invalid zstd stream: %v", err)
+		}
+		return zr.IOReadCloser(), nil
+	default:
+		return io.NopCloser(br), nil
+	}
+}
+
+// Open opens the named file and returns a reader over its decompressed content
+func Open(name string) (io.ReadCloser, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &fileReader{ReadCloser: reader, file: file}, nil
+}
+
+// IsCompressed reports whether the file starts with a known compression header.
+// The file offset is left untouched.
+func IsCompressed(file *os.File) (bool, error) {
+	header := make([]byte, headerSize)
+	n, err := file.ReadAt(header, 0)
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	return Detect(header[:n]) != CompressionNone, nil
+}
+
+// fileReader closes both the decompressor and the underlying file
+type fileReader struct {
+	io.ReadCloser
+	file *os.File
+}
+
+func (f *fileReader) Close() error {
+	err := f.ReadCloser.Close()
+	if cerr := f.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
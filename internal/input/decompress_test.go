@@ -0,0 +1,181 @@
+package input
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+const sampleContent = "first line\nsecond line\n"
+
+// bzip2Sample is sampleContent compressed with bzip2, as the stdlib has no bzip2 writer
+var bzip2Sample = []byte{
+	0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0x8b, 0x13,
+	0xe1, 0x84, 0x00, 0x00, 0x04, 0xd1, 0x80, 0x00, 0x10, 0x40, 0x00, 0x0f,
+	0x25, 0x9c, 0x00, 0x20, 0x00, 0x21, 0xa1, 0x32, 0x31, 0x94, 0x20, 0x1a,
+	0x00, 0x91, 0x2a, 0x31, 0x95, 0x68, 0xcb, 0x04, 0x82, 0xfd, 0x57, 0xf1,
+	0x77, 0x24, 0x53, 0x85, 0x09, 0x08, 0xb1, 0x3e, 0x18, 0x40,
+}
+
+func compressGzip(t *testing.T, data string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(data)); err != nil {
+		t.Fatalf("gzip write failed: %v", err)
+	}
+	w.Close()
+	return buf.Bytes()
+}
+
+func compressXz(t *testing.T, data string) []byte {
+	var buf bytes.Buffer
+	w, err := xz.NewWriter(&buf)
+	if err != nil {
+		t.Fatalf("xz writer failed: %v", err)
+	}
+	if _, err := w.Write([]byte(data)); err != nil {
+		t.Fatalf("xz write failed: %v", err)
+	}
+	w.Close()
+	return buf.Bytes()
+}
+
+func compressZstd(t *testing.T, data string) []byte {
+	w, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("zstd writer failed: %v", err)
+	}
+	defer w.Close()
+	return w.EncodeAll([]byte(data), nil)
+}
+
+func TestNewReader(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        []byte
+		compression Compression
+	}{
+		{
+			name:        "Plain text",
+			data:        []byte(sampleContent),
+			compression: CompressionNone,
+		},
+		{
+			name:        "Gzip",
+			data:        compressGzip(t, sampleContent),
+			compression: CompressionGzip,
+		},
+		{
+			name:        "Bzip2",
+			data:        bzip2Sample,
+			compression: CompressionBzip2,
+		},
+		{
+			name:        "Xz",
+			data:        compressXz(t, sampleContent),
+			compression: CompressionXz,
+		},
+		{
+			name:        "Zstd",
+			data:        compressZstd(t, sampleContent),
+			compression: CompressionZstd,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(tt.data); got != tt.compression {
+				t.Errorf("Detect() = %v, want %v", got, tt.compression)
+			}
+
+			reader, err := NewReader(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatalf("NewReader() unexpected error: %v", err)
+			}
+			defer reader.Close()
+
+			content, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("reading decompressed content failed: %v", err)
+			}
+
+			if string(content) != sampleContent {
+				t.Errorf("NewReader() content = %q, want %q", content, sampleContent)
+			}
+		})
+	}
+}
+
+func TestDetectTextStartingLikeBzip2(t *testing.T) {
+	for _, text := range []string{"BZh\n", "BZh9 is not a header\n", "BZhello world\nsecond line\n"} {
+		if got := Detect([]byte(text)); got != CompressionNone {
+			t.Errorf("Detect(%q) = %v, want none", text, got)
+		}
+
+		reader, err := NewReader(bytes.NewReader([]byte(text)))
+		if err != nil {
+			t.Fatalf("NewReader(%q) unexpected error: %v", text, err)
+		}
+		content, err := io.ReadAll(reader)
+		if err != nil || string(content) != text {
+			t.Errorf("NewReader(%q) content = %q, %v, want the text unchanged", text, content, err)
+		}
+	}
+}
+
+func TestNewReaderShortInput(t *testing.T) {
+	for _, input := range []string{"", "a", "ab\n"} {
+		reader, err := NewReader(bytes.NewReader([]byte(input)))
+		if err != nil {
+			t.Fatalf("NewReader(%q) unexpected error: %v", input, err)
+		}
+
+		content, _ := io.ReadAll(reader)
+		if string(content) != input {
+			t.Errorf("NewReader(%q) content = %q", input, content)
+		}
+	}
+}
+
+func TestOpenAndIsCompressed(t *testing.T) {
+	tempDir := t.TempDir()
+	plainFile := filepath.Join(tempDir, "app.log")
+	gzipFile := filepath.Join(tempDir, "app.log.1.gz")
+
+	if err := os.WriteFile(plainFile, []byte(sampleContent), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(gzipFile, compressGzip(t, sampleContent), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	for name, wantCompressed := range map[string]bool{plainFile: false, gzipFile: true} {
+		reader, err := Open(name)
+		if err != nil {
+			t.Fatalf("Open(%s) unexpected error: %v", name, err)
+		}
+		content, _ := io.ReadAll(reader)
+		reader.Close()
+
+		if string(content) != sampleContent {
+			t.Errorf("Open(%s) content = %q, want %q", name, content, sampleContent)
+		}
+
+		file, err := os.Open(name)
+		if err != nil {
+			t.Fatalf("Cannot open test file: %v", err)
+		}
+		compressed, err := IsCompressed(file)
+		file.Close()
+
+		if err != nil || compressed != wantCompressed {
+			t.Errorf("IsCompressed(%s) = %v, %v; want %v", name, compressed, err, wantCompressed)
+		}
+	}
+}
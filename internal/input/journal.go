@@ -0,0 +1,170 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// journalExportHeaders are the fields starting the entries of the journal
+// export format, of the same length so that a single peek tells them
+var journalExportHeaders = [][]byte{[]byte("__CURSOR="), []byte("__REALTIM")}
+
+// NewJournalReader wraps r so that the journal export format written by
+// journalctl -o export is converted to JSON lines, one per entry, as written
+// by journalctl -o json. Other content is passed through unchanged.
+func NewJournalReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+
+	// Live streams starting with a short line are not held back by the
+	// peek unless they may be journal exports
+	header, err := br.Peek(2)
+	if err == nil && string(header) == "__" {
+		header, err = br.Peek(len(journalExportHeaders[0]))
+	}
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	for _, h := range journalExportHeaders {
+		if bytes.Equal(header, h) {
+			return &journalReader{reader: br}, nil
+		}
+	}
+	return br, nil
+}
+
+// journalReader converts the journal export format to JSON lines
+type journalReader struct {
+	reader  *bufio.Reader
+	pending bytes.Buffer
+	value   bytes.Buffer
+	err     error
+}
+
+// Read returns the JSON lines of the entries read so far
+func (jr *journalReader) Read(p []byte) (int, error) {
+	for jr.pending.Len() == 0 {
+		if jr.err != nil {
+			return 0, jr.err
+		}
+		if jr.err = jr.next(); jr.err != nil {
+			// Drop the incomplete entry
+			jr.pending.Reset()
+		}
+	}
+	return jr.pending.Read(p)
+}
+
+// next converts the next entry. Entries are lists of fields ended by an empty
+// line. Text fields are written as KEY=value lines, while binary ones, which
+// may hold newlines, are written as the key alone on its line followed by
+// the length of the value as a little-endian 64-bit integer, the value and a
+// newline.
+func (jr *journalReader) next() error {
+	fields := 0
+	for {
+		line, err := jr.reader.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			// Text values have no length limit
+			long := append([]byte(nil), line...)
+			for err == bufio.ErrBufferFull {
+				line, err = jr.reader.ReadSlice('\n')
+				long = append(long, line...)
+			}
+			line = long
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		line = bytes.TrimSuffix(line, []byte{'\n'})
+		if len(line) == 0 {
+			if fields > 0 {
+				jr.pending.WriteString("}\n")
+				return nil
+			}
+			if err == io.EOF {
+				return io.EOF
+			}
+			continue
+		}
+
+		if fields == 0 {
+			jr.pending.WriteByte('{')
+		} else {
+			jr.pending.WriteByte(',')
+		}
+		fields++
+
+		if key, value, ok := bytes.Cut(line, []byte{'='}); ok {
+			writeJournalField(&jr.pending, key, value)
+		} else if err == nil {
+			// The key must be copied before the reader moves on
+			key := string(line)
+			if err := jr.readBinary(); err != nil {
+				return fmt.Errorf("invalid journal export field %s: %v", key, err)
+			}
+			writeJournalField(&jr.pending, []byte(key), jr.value.Bytes())
+		} else {
+			writeJournalField(&jr.pending, line, nil)
+		}
+
+		if err == io.EOF {
+			// The stream ends with an entry without its empty line
+			jr.pending.WriteString("}\n")
+			return nil
+		}
+	}
+}
+
+// readBinary reads the value of a binary field into jr.value
+func (jr *journalReader) readBinary() error {
+	var size [8]byte
+	if _, err := io.ReadFull(jr.reader, size[:]); err != nil {
+		return err
+	}
+
+	jr.value.Reset()
+	n := binary.LittleEndian.Uint64(size[:])
+	if copied, err := io.CopyN(&jr.value, jr.reader, int64(n)); err != nil {
+		return fmt.Errorf("value truncated at %d of %d bytes", copied, n)
+	}
+	if newline, err := jr.reader.ReadByte(); err != nil || newline != '\n' {
+		return fmt.Errorf("no newline after the value")
+	}
+	return nil
+}
+
+// writeJournalField writes a field as a JSON key and value. Values that are
+// not valid UTF-8 are written as arrays of bytes, like journalctl -o json does.
+func writeJournalField(b *bytes.Buffer, key, value []byte) {
+	writeJSONString(b, key)
+	b.WriteByte(':')
+	if utf8.Valid(value) {
+		writeJSONString(b, value)
+		return
+	}
+
+	b.WriteByte('[')
+	for i, c := range value {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprint(b, c)
+	}
+	b.WriteByte(']')
+}
+
+// writeJSONString writes a valid UTF-8 string as JSON, leaving HTML
+// characters alone so that they read the same in raw output
+func writeJSONString(b *bytes.Buffer, s []byte) {
+	encoder := json.NewEncoder(b)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(string(s))
+	// Encode ends the value with a newline
+	b.Truncate(b.Len() - 1)
+}
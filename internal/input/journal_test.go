@@ -0,0 +1,78 @@
+package input
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestJournalReaderConvertsExport(t *testing.T) {
+	export, err := os.Open("testdata/journal.export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer export.Close()
+	want, err := os.ReadFile("testdata/journal.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := NewJournalReader(export)
+	if err != nil {
+		t.Fatalf("NewJournalReader failed: %v", err)
+	}
+	got, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("converted export =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestJournalReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "Plain text",
+			input: "__init__ called\nsecond line\n",
+			want:  "__init__ called\nsecond line\n",
+		},
+		{
+			name:  "Short input",
+			input: "_",
+			want:  "_",
+		},
+		{
+			name:  "Last entry without its empty line",
+			input: "__CURSOR=a\nMESSAGE=<b>\"quoted\"</b>",
+			want:  `{"__CURSOR":"a","MESSAGE":"<b>\"quoted\"</b>"}` + "\n",
+		},
+		{
+			name:    "Truncated binary field",
+			input:   "__CURSOR=a\nMESSAGE\n\x10\x00\x00\x00\x00\x00\x00\x00short",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := NewJournalReader(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("NewJournalReader failed: %v", err)
+			}
+			got, err := io.ReadAll(reader)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("read error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("read %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
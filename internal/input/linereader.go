@@ -0,0 +1,187 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+const (
+	// readBufferSize is large enough to hold the binary detection sample
+	readBufferSize = 64 * 1024
+
+	// binarySampleSize is how much of a stream is inspected by LooksBinary
+	binarySampleSize = 8 * 1024
+
+	// binaryNULRatio is the proportion of NUL bytes above which input is binary
+	binaryNULRatio = 0.1
+)
+
+// LineReader reads lines of arbitrary length. Unlike bufio.Scanner it never
+// fails on long lines: they are either returned whole or, when a maximum
+// length is set, truncated with a visible marker.
+type LineReader struct {
+	reader      *bufio.Reader
+	maxLength   int
+	holdPartial bool
+	line        []byte
+	tail        [2]byte
+	total       int
+	offset      int64
+}
+
+// NewLineReader returns a LineReader over r. A maxLength of zero or less
+// disables truncation.
+func NewLineReader(r io.Reader, maxLength int) *LineReader {
+	return &LineReader{
+		reader:    bufio.NewReaderSize(r, readBufferSize),
+		maxLength: maxLength,
+	}
+}
+
+// SetHoldPartial controls what happens to a final line without a trailing
+// newline. When hold is true the fragment stays buffered and is completed by
+// later reads, which is what follow mode needs while a writer is mid-line.
+func (lr *LineReader) SetHoldPartial(hold bool) {
+	lr.holdPartial = hold
+}
+
+// ReadLine returns the next line without its line terminator. A final line
+// without a trailing newline is returned as well unless partial lines are
+// held; io.EOF is returned once no complete line remains.
+func (lr *LineReader) ReadLine() (string, error) {
+	for {
+		chunk, err := lr.reader.ReadSlice('\n')
+		lr.offset += int64(len(chunk))
+		lr.total += len(chunk)
+		lr.keep(chunk)
+
+		switch err {
+		case bufio.ErrBufferFull:
+			continue
+		case nil:
+			return lr.finish(), nil
+		case io.EOF:
+			if lr.total == 0 || lr.holdPartial {
+				return "", io.EOF
+			}
+			return lr.finish(), nil
+		default:
+			return "", err
+		}
+	}
+}
+
+// Pending returns the number of bytes of an incomplete line held in the buffer
+func (lr *LineReader) Pending() int {
+	return lr.total
+}
+
+// Flush returns the held incomplete line, if any, as a complete line
+func (lr *LineReader) Flush() (string, bool) {
+	if lr.total == 0 {
+		return "", false
+	}
+	return lr.finish(), true
+}
+
+// Offset returns the number of bytes consumed from the underlying reader
+func (lr *LineReader) Offset() int64 {
+	return lr.offset
+}
+
+// ReadChunk returns about size bytes made of whole lines, extended up to the
+// next newline, so that lines can be split off and processed in parallel. The
+// last chunk of the stream may end without a newline. It must not be mixed
+// with ReadLine calls holding a partial line.
+func (lr *LineReader) ReadChunk(size int) ([]byte, error) {
+	chunk := make([]byte, size)
+	n, err := io.ReadFull(lr.reader, chunk)
+	chunk = chunk[:n]
+
+	switch {
+	case err == io.EOF:
+		return nil, io.EOF
+	case err == io.ErrUnexpectedEOF:
+		// Short final chunk
+	case err != nil:
+		return nil, err
+	case chunk[n-1] != '\n':
+		rest, err := lr.reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		chunk = append(chunk, rest...)
+	}
+
+	lr.offset += int64(len(chunk))
+	return chunk, nil
+}
+
+// SetOffset sets the offset reported by Offset, for readers that do not start
+// at the beginning of their file
+func (lr *LineReader) SetOffset(offset int64) {
+	lr.offset = offset
+}
+
+// LooksBinary reports whether the start of the stream is dominated by NUL
+// bytes, which is typical of binary files and UTF-16 text
+func (lr *LineReader) LooksBinary() bool {
+	sample, _ := lr.reader.Peek(binarySampleSize)
+	if len(sample) == 0 {
+		return false
+	}
+	nuls := bytes.Count(sample, []byte{0})
+	return float64(nuls)/float64(len(sample)) > binaryNULRatio
+}
+
+// keep buffers a chunk of the current line, up to the maximum length
+func (lr *LineReader) keep(chunk []byte) {
+	switch n := len(chunk); {
+	case n >= 2:
+		lr.tail = [2]byte{chunk[n-2], chunk[n-1]}
+	case n == 1:
+		lr.tail = [2]byte{lr.tail[1], chunk[0]}
+	}
+
+	if lr.maxLength <= 0 {
+		lr.line = append(lr.line, chunk...)
+		return
+	}
+	// One extra byte tells whether the cut falls inside a multi-byte character
+	if room := lr.maxLength + 1 - len(lr.line); room > 0 {
+		lr.line = append(lr.line, chunk[:min(room, len(chunk))]...)
+	}
+}
+
+// finish strips the line terminator, applies truncation and resets the buffer
+// for the next line
+func (lr *LineReader) finish() string {
+	defer func() {
+		lr.line = lr.line[:0]
+		lr.tail = [2]byte{}
+		lr.total = 0
+	}()
+
+	content := lr.total
+	if lr.tail[1] == '\n' {
+		content--
+		if content > 0 && lr.tail[0] == '\r' {
+			content--
+		}
+	}
+
+	if lr.maxLength <= 0 || content <= lr.maxLength {
+		return string(lr.line[:content])
+	}
+
+	// Never cut a multi-byte character in half
+	kept := lr.maxLength
+	for kept > 0 && !utf8.RuneStart(lr.line[kept]) {
+		kept--
+	}
+
+	return string(lr.line[:kept]) + fmt.Sprintf(" [... truncated %d bytes]", content-kept)
+}
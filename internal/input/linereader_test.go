@@ -0,0 +1,171 @@
+package input
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func readAllLines(t *testing.T, lr *LineReader) []string {
+	var lines []string
+	for {
+		line, err := lr.ReadLine()
+		if err == io.EOF {
+			return lines
+		}
+		if err != nil {
+			t.Fatalf("ReadLine() unexpected error: %v", err)
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestLineReader(t *testing.T) {
+	longLine := strings.Repeat("x", 2*1024*1024)
+
+	tests := []struct {
+		name      string
+		input     string
+		maxLength int
+		want      []string
+	}{
+		{
+			name:  "Simple lines",
+			input: "first\nsecond\n",
+			want:  []string{"first", "second"},
+		},
+		{
+			name:  "Final line without newline",
+			input: "first\nsecond",
+			want:  []string{"first", "second"},
+		},
+		{
+			name:  "CRLF line endings",
+			input: "first\r\nsecond\r\n",
+			want:  []string{"first", "second"},
+		},
+		{
+			name:  "Empty lines are preserved",
+			input: "\n\nlast\n",
+			want:  []string{"", "", "last"},
+		},
+		{
+			name:  "Line longer than the read buffer",
+			input: longLine + "\nafter\n",
+			want:  []string{longLine, "after"},
+		},
+		{
+			name:      "Truncated long line",
+			input:     longLine + "\nafter\n",
+			maxLength: 10,
+			want:      []string{"xxxxxxxxxx [... truncated 2097142 bytes]", "after"},
+		},
+		{
+			name:      "Line of exactly the maximum length",
+			input:     "0123456789\r\nafter",
+			maxLength: 10,
+			want:      []string{"0123456789", "after"},
+		},
+		{
+			name:      "Truncation keeps characters whole",
+			input:     "héllo\n",
+			maxLength: 2,
+			want:      []string{"h [... truncated 5 bytes]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lr := NewLineReader(strings.NewReader(tt.input), tt.maxLength)
+
+			if got := readAllLines(t, lr); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadLine() lines = %.80q, want %.80q", got, tt.want)
+			}
+
+			if lr.Offset() != int64(len(tt.input)) {
+				t.Errorf("Offset() = %d, want %d", lr.Offset(), len(tt.input))
+			}
+		})
+	}
+}
+
+func TestLooksBinary(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{name: "Empty input", input: "", want: false},
+		{name: "Text log", input: "2024-09-30 INFO started\n", want: false},
+		{name: "Stray NUL in text", input: strings.Repeat("text ", 100) + "\x00", want: false},
+		{name: "UTF-16 text", input: "I\x00N\x00F\x00O\x00\n\x00", want: true},
+		{name: "Binary data", input: "\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lr := NewLineReader(strings.NewReader(tt.input), 0)
+			if got := lr.LooksBinary(); got != tt.want {
+				t.Errorf("LooksBinary() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLineReaderHoldPartial(t *testing.T) {
+	var file bytes.Buffer
+	lr := NewLineReader(&file, 0)
+	lr.SetHoldPartial(true)
+
+	// The writer has flushed one full line and half of the next one
+	file.WriteString("complete\nhalf of a")
+
+	if got := readAllLines(t, lr); !reflect.DeepEqual(got, []string{"complete"}) {
+		t.Errorf("ReadLine() lines = %q, want only the complete line", got)
+	}
+	if lr.Pending() != len("half of a") {
+		t.Errorf("Pending() = %d, want %d", lr.Pending(), len("half of a"))
+	}
+
+	// The rest of the line arrives on the next poll
+	file.WriteString(" line\r\ntail")
+
+	if got := readAllLines(t, lr); !reflect.DeepEqual(got, []string{"half of a line"}) {
+		t.Errorf("ReadLine() lines = %q, want the reassembled line", got)
+	}
+
+	// The writer never completes the last line: flushing emits it once
+	if line, ok := lr.Flush(); !ok || line != "tail" {
+		t.Errorf("Flush() = %q, %v; want %q, true", line, ok, "tail")
+	}
+	if _, ok := lr.Flush(); ok {
+		t.Error("Flush() should have nothing left to return")
+	}
+}
+
+func TestLineReaderReadChunk(t *testing.T) {
+	input := "first line\nsecond line\n" + strings.Repeat("y", 100) + "\nlast"
+	lr := NewLineReader(strings.NewReader(input), 0)
+
+	var chunks []string
+	for {
+		chunk, err := lr.ReadChunk(15)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ReadChunk() unexpected error: %v", err)
+		}
+		chunks = append(chunks, string(chunk))
+	}
+
+	want := []string{"first line\nsecond line\n", strings.Repeat("y", 100) + "\n", "last"}
+	if !reflect.DeepEqual(chunks, want) {
+		t.Errorf("ReadChunk() chunks = %q, want %q", chunks, want)
+	}
+	if lr.Offset() != int64(len(input)) {
+		t.Errorf("Offset() = %d, want %d", lr.Offset(), len(input))
+	}
+}
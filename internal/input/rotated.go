@@ -0,0 +1,155 @@
+package input
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"logtail/internal/parser"
+)
+
+// compressionSuffixes are the extensions logrotate appends to compressed archives
+var compressionSuffixes = []string{".gz", ".bz2", ".xz", ".zst"}
+
+var (
+	// app.log.1, app.log.2.gz
+	numericSuffix = regexp.MustCompile(`^[.-](\d{1,6})$`)
+	// app.log-20240930, app.log.2024-09-30, app.log-20240930-1727692245
+	dateSuffix = regexp.MustCompile(`^[.-](\d{4})-?(\d{2})-?(\d{2})(?:[-_.]?(\d+))?$`)
+)
+
+// timestampProbeLines bounds how far into a file we look for its first timestamp
+const timestampProbeLines = 50
+
+// rotatedFile is a sibling of a live log file produced by log rotation
+type rotatedFile struct {
+	path   string
+	number int
+	date   time.Time
+	// seq orders the rotations of a same date: a counter or an epoch
+	seq      int
+	hasDate  bool
+	firstLog time.Time
+}
+
+// RotatedFamily returns the live log file at path preceded by its rotated
+// siblings, ordered from oldest to newest. Siblings are ordered by numeric
+// suffix or date suffix when all of them share the same scheme, and by the
+// first timestamp found in their content otherwise, read from lines truncated
+// to maxLineLength like NewLineReader does.
+func RotatedFamily(path string, maxLineLength int) ([]string, error) {
+	dir := filepath.Dir(path)
+	base := filepath.Base(path)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var siblings []rotatedFile
+	allNumeric, allDated := true, true
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name == base || !strings.HasPrefix(name, base) {
+			continue
+		}
+
+		suffix := trimCompressionSuffix(strings.TrimPrefix(name, base))
+		sibling := rotatedFile{path: filepath.Join(dir, name)}
+
+		if matches := numericSuffix.FindStringSubmatch(suffix); matches != nil {
+			sibling.number, _ = strconv.Atoi(matches[1])
+			allDated = false
+		} else if matches := dateSuffix.FindStringSubmatch(suffix); matches != nil {
+			date, err := time.Parse("20060102", matches[1]+matches[2]+matches[3])
+			if err != nil {
+				continue
+			}
+			if matches[4] != "" {
+				// Sub-day rotations carry an extra counter or epoch
+				sibling.seq, _ = strconv.Atoi(matches[4])
+			}
+			sibling.date = date
+			sibling.hasDate = true
+			allNumeric = false
+		} else {
+			continue
+		}
+
+		siblings = append(siblings, sibling)
+	}
+
+	switch {
+	case allNumeric:
+		// Higher numbers are older rotations
+		sort.SliceStable(siblings, func(i, j int) bool {
+			return siblings[i].number > siblings[j].number
+		})
+	case allDated:
+		sort.SliceStable(siblings, func(i, j int) bool {
+			if !siblings[i].date.Equal(siblings[j].date) {
+				return siblings[i].date.Before(siblings[j].date)
+			}
+			return siblings[i].seq < siblings[j].seq
+		})
+	default:
+		for i := range siblings {
+			siblings[i].firstLog = firstTimestamp(siblings[i].path, maxLineLength)
+		}
+		sort.SliceStable(siblings, func(i, j int) bool {
+			return siblings[i].firstLog.Before(siblings[j].firstLog)
+		})
+	}
+
+	family := make([]string, 0, len(siblings)+1)
+	for _, sibling := range siblings {
+		family = append(family, sibling.path)
+	}
+
+	// The live file may not exist yet right after a rotation
+	if _, err := os.Stat(path); err == nil {
+		family = append(family, path)
+	}
+
+	return family, nil
+}
+
+// trimCompressionSuffix removes a trailing compression extension, if any
+func trimCompressionSuffix(name string) string {
+	for _, suffix := range compressionSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return strings.TrimSuffix(name, suffix)
+		}
+	}
+	return name
+}
+
+// firstTimestamp returns the first timestamp found near the start of a file.
+// Files without one fall back to their modification time.
+func firstTimestamp(path string, maxLineLength int) time.Time {
+	reader, err := Open(path)
+	if err == nil {
+		defer reader.Close()
+
+		lines := NewLineReader(reader, maxLineLength)
+		for i := 0; i < timestampProbeLines; i++ {
+			line, err := lines.ReadLine()
+			if err != nil {
+				break
+			}
+			if entry := parser.ParseLogLine(line); !entry.Timestamp.IsZero() {
+				return entry.Timestamp
+			}
+		}
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
@@ -0,0 +1,138 @@
+package input
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+}
+
+func TestRotatedFamily(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name: "Numeric suffixes",
+			files: map[string]string{
+				"app.log":       "live\n",
+				"app.log.1":     "newest archive\n",
+				"app.log.2.gz":  "",
+				"app.log.10.xz": "",
+				"other.log.1":   "unrelated\n",
+			},
+			want: []string{"app.log.10.xz", "app.log.2.gz", "app.log.1", "app.log"},
+		},
+		{
+			name: "Date suffixes",
+			files: map[string]string{
+				"app.log":             "live\n",
+				"app.log-20240930":    "",
+				"app.log-20240815.gz": "",
+				"app.log.2024-09-01":  "",
+			},
+			want: []string{"app.log-20240815.gz", "app.log.2024-09-01", "app.log-20240930", "app.log"},
+		},
+		{
+			name: "Rotations within a day",
+			files: map[string]string{
+				"app.log":             "live\n",
+				"app.log-20240930-10": "",
+				"app.log-20240930-2":  "",
+				"app.log-20241001-1":  "",
+				"app.log-20240929-99": "",
+			},
+			want: []string{"app.log-20240929-99", "app.log-20240930-2", "app.log-20240930-10", "app.log-20241001-1", "app.log"},
+		},
+		{
+			name: "Mixed schemes ordered by first timestamp",
+			files: map[string]string{
+				"app.log":          "2024-10-02T00:00:00Z INFO live\n",
+				"app.log.1":        "2024-10-01T00:00:00Z INFO yesterday\n",
+				"app.log-20240901": "2024-09-01T00:00:00Z INFO last month\n",
+			},
+			want: []string{"app.log-20240901", "app.log.1", "app.log"},
+		},
+		{
+			name: "Unrecognized suffixes are ignored",
+			files: map[string]string{
+				"app.log":      "live\n",
+				"app.log.bak":  "",
+				"app.logger.1": "",
+			},
+			want: []string{"app.log"},
+		},
+		{
+			name: "Live file missing after rotation",
+			files: map[string]string{
+				"app.log.1": "",
+			},
+			want: []string{"app.log.1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+
+			family, err := RotatedFamily(filepath.Join(dir, "app.log"), 0)
+			if err != nil {
+				t.Fatalf("RotatedFamily() unexpected error: %v", err)
+			}
+
+			got := make([]string, len(family))
+			for i, path := range family {
+				got[i] = filepath.Base(path)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RotatedFamily() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRotatedFamilyLongFirstLines(t *testing.T) {
+	dir := t.TempDir()
+	body := strings.Repeat("x", 100*1024)
+	writeFiles(t, dir, map[string]string{
+		"app.log":          "2024-10-02T00:00:00Z INFO live\n",
+		"app.log.1":        "2024-10-01T00:00:00Z INFO body=" + body + "\n",
+		"app.log-20240901": "2024-09-01T00:00:00Z INFO body=" + body + "\n",
+	})
+
+	// Modification times in the opposite order, so that falling back to
+	// them shows
+	for i, name := range []string{"app.log-20240901", "app.log.1"} {
+		mtime := time.Date(2024, 10, 3-i, 0, 0, 0, 0, time.UTC)
+		if err := os.Chtimes(filepath.Join(dir, name), mtime, mtime); err != nil {
+			t.Fatalf("Failed to set modification time: %v", err)
+		}
+	}
+
+	for _, limit := range []int{0, 1024} {
+		family, err := RotatedFamily(filepath.Join(dir, "app.log"), limit)
+		if err != nil {
+			t.Fatalf("RotatedFamily() unexpected error: %v", err)
+		}
+		got := make([]string, len(family))
+		for i, path := range family {
+			got[i] = filepath.Base(path)
+		}
+		if want := []string{"app.log-20240901", "app.log.1", "app.log"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("RotatedFamily() with lines limited to %d = %v, want %v", limit, got, want)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parseSecurityEvent parses a CEF or LEEF event, usually carried by syslog
+// after its header, as in
+//
+//	Sep 30 10:30:45 fw-1 CEF:0|Vendor|Product|1.0|100|Port scan|7|src=10.0.0.1 act=blocked
+//	Sep 30 10:30:45 fw-1 LEEF:1.0|Vendor|Product|1.0|PortScan|src=10.0.0.1	sev=7
+//
+// The product is the source of the entry, the name of a CEF event or the
+// event ID of a LEEF one its message, and the other header fields and the
+// extension attributes its fields.
+func parseSecurityEvent(line string) (LogEntry, bool) {
+	at := strings.Index(line, "EF:")
+	if at < 1 || at+3 >= len(line) || !isDigit(line[at+3]) {
+		return LogEntry{}, false
+	}
+
+	var format Format
+	var start int
+	switch {
+	case line[at-1] == 'C':
+		format, start = FormatCEF, at-1
+	case at >= 2 && line[at-2:at] == "LE":
+		format, start = FormatLEEF, at-2
+	default:
+		return LogEntry{}, false
+	}
+	if start > 0 && line[start-1] != ' ' {
+		return LogEntry{}, false
+	}
+
+	// CEF:Version|Vendor|Product|Version|Signature ID|Name|Severity|
+	// LEEF:Version|Vendor|Product|Version|Event ID|
+	fields := 7
+	if format == FormatLEEF {
+		fields = 5
+	}
+	header, end, ok := splitHeader(line, start, fields)
+	if !ok {
+		return LogEntry{}, false
+	}
+
+	entry := LogEntry{Raw: line, Level: LevelUnknown, Format: format}
+	entry.Source, entry.SourceSpan = unescapeCEF(line[header[2].Start:header[2].End]), header[2]
+	entry.Fields = append(entry.Fields,
+		Field{"device_vendor", unescapeCEF(line[header[1].Start:header[1].End])},
+		Field{"device_version", unescapeCEF(line[header[3].Start:header[3].End])})
+
+	if format == FormatCEF {
+		entry.Fields = append(entry.Fields, Field{"signature_id", unescapeCEF(line[header[4].Start:header[4].End])})
+		entry.Message = unescapeCEF(line[header[5].Start:header[5].End])
+		if level, ok := severityLevel(line[header[6].Start:header[6].End]); ok {
+			entry.Level, entry.LevelSpan, entry.LevelConfidence = level, header[6], markedScore
+		}
+		parseCEFExtension(&entry, line, end)
+	} else {
+		entry.Message = unescapeCEF(line[header[4].Start:header[4].End])
+		delimiter := byte('\t')
+		if line[start+5:header[0].End] == "2.0" {
+			delimiter, end = leefDelimiter(line, end)
+		}
+		parseLEEFAttributes(&entry, line, end, delimiter)
+	}
+
+	if entry.TimestampSpan.IsEmpty() {
+		headerTimestamp(&entry, line, start)
+	}
+	return entry, true
+}
+
+// splitHeader returns the spans of the n fields of the header starting at
+// line[start], each ended by a pipe, and the start of what follows them
+func splitHeader(line string, start, n int) ([]Span, int, bool) {
+	fields := make([]Span, 0, n)
+	from := start
+	for i := start; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '|':
+			fields = append(fields, Span{from, i})
+			from = i + 1
+			if len(fields) == n {
+				return fields, from, true
+			}
+		}
+	}
+	return nil, 0, false
+}
+
+// parseCEFExtension parses the key=value pairs of the extension of a CEF
+// event starting at line[start]. Values may hold spaces: each one runs until
+// the next key.
+func parseCEFExtension(entry *LogEntry, line string, start int) {
+	for i := skipSpaces(line, start); i < len(line); {
+		end := cefKeyEnd(line, i)
+		if end == i || end >= len(line) || line[end] != '=' {
+			return
+		}
+		key := line[i:end]
+
+		i = end + 1
+		end = cefValueEnd(line, i)
+		setAttribute(entry, key, unescapeCEF(line[i:end]), i, end)
+		i = skipSpaces(line, end)
+	}
+}
+
+// cefKeyEnd returns the end of the extension key starting at line[i]
+func cefKeyEnd(line string, i int) int {
+	for i < len(line) && (isWordByte(line[i]) || line[i] == '.' || line[i] == '-' || line[i] == '[' || line[i] == ']') {
+		i++
+	}
+	return i
+}
+
+// cefValueEnd returns the end of the extension value starting at line[i],
+// trailing spaces excluded
+func cefValueEnd(line string, i int) int {
+	end := len(line)
+	for j := i; j < len(line); j++ {
+		switch line[j] {
+		case '\\':
+			j++
+		case ' ':
+			if k := cefKeyEnd(line, j+1); k > j+1 && k < len(line) && line[k] == '=' {
+				end = j
+				j = len(line)
+			}
+		}
+	}
+	for end > i && line[end-1] == ' ' {
+		end--
+	}
+	return end
+}
+
+// leefDelimiter reads the optional attribute delimiter of a LEEF 2.0 header,
+// a character or its code in hexadecimal, as in ^ or x5E, which follows the
+// event ID. It returns the delimiter, a tab by default, and the start of the
+// attributes.
+func leefDelimiter(line string, start int) (byte, int) {
+	end := strings.IndexByte(line[start:], '|')
+	if end < 0 {
+		return '\t', start
+	}
+	field := line[start : start+end]
+
+	switch {
+	case len(field) == 1:
+		return field[0], start + end + 1
+	case len(field) >= 3 && len(field) <= 4 && (field[0] == 'x' || field[:2] == "0x"):
+		code := field[strings.IndexByte(field, 'x')+1:]
+		if c, err := strconv.ParseUint(code, 16, 8); err == nil {
+			return byte(c), start + end + 1
+		}
+	}
+	return '\t', start
+}
+
+// parseLEEFAttributes parses the key=value attributes of a LEEF event
+// starting at line[start], separated by delimiter
+func parseLEEFAttributes(entry *LogEntry, line string, start int, delimiter byte) {
+	for i := start; i < len(line); {
+		end := strings.IndexByte(line[i:], delimiter)
+		if end < 0 {
+			end = len(line)
+		} else {
+			end += i
+		}
+
+		if key, value, ok := strings.Cut(line[i:end], "="); ok && key != "" {
+			valueStart := i + len(key) + 1
+			setAttribute(entry, strings.TrimSpace(key), value, valueStart, end)
+		}
+		i = end + 1
+	}
+}
+
+// setAttribute fills the part of entry held by an extension attribute of a
+// CEF or LEEF event, at line[start:end], or appends it to its Fields
+func setAttribute(entry *LogEntry, key, value string, start, end int) {
+	switch key {
+	case "rt", "end", "devTime":
+		if entry.TimestampSpan.IsEmpty() {
+			if t, err := parseTimestamp(value); err == nil {
+				entry.Timestamp, entry.TimestampSpan = t, Span{start, end}
+				return
+			}
+		}
+	case "sev":
+		if entry.LevelSpan.IsEmpty() {
+			if level, ok := severityLevel(value); ok {
+				entry.Level, entry.LevelSpan, entry.LevelConfidence = level, Span{start, end}, markedScore
+				return
+			}
+		}
+	}
+	entry.Fields = append(entry.Fields, Field{Key: key, Value: value})
+}
+
+// severityLevel returns the level of a CEF or LEEF severity, from 0 to 10 or
+// named: 0 to 3 are low, 4 to 6 medium, 7 and 8 high and 9 and 10 very high
+func severityLevel(value string) (LogLevel, bool) {
+	switch strings.ToLower(value) {
+	case "low":
+		return LevelInfo, true
+	case "medium":
+		return LevelWarn, true
+	case "high":
+		return LevelError, true
+	case "very-high", "very high":
+		return LevelCritical, true
+	}
+
+	n, err := strconv.Atoi(value)
+	switch {
+	case err != nil || n < 0 || n > 10:
+		return "", false
+	case n <= 3:
+		return LevelInfo, true
+	case n <= 6:
+		return LevelWarn, true
+	case n <= 8:
+		return LevelError, true
+	default:
+		return LevelCritical, true
+	}
+}
+
+// headerTimestamp sets the timestamp of an event from the syslog header
+// before it, ending at line[end]
+func headerTimestamp(entry *LogEntry, line string, end int) {
+	for i := 0; i < end; i++ {
+		if !isWordByte(line[i]) || (i > 0 && isWordByte(line[i-1])) {
+			continue
+		}
+		if t, _, timeEnd, ok := scanTimestamp(line[:end], i); ok {
+			entry.Timestamp, entry.TimestampSpan = t, Span{i, timeEnd}
+			return
+		}
+	}
+}
+
+// unescapeCEF returns the text of a CEF value, whose backslashes escape
+// pipes, equal signs, backslashes and newlines
+func unescapeCEF(s string) string {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\\' && i+1 < len(s) {
+			i++
+			switch c = s[i]; c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
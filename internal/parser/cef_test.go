@@ -0,0 +1,127 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSecurityEvent(t *testing.T) {
+	// Syslog headers have no year
+	originalNow := now
+	defer func() {
+		now = originalNow
+	}()
+	now = func() time.Time { return time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name          string
+		input         string
+		wantFormat    Format
+		wantLevel     LogLevel
+		wantTimestamp time.Time
+		wantMessage   string
+		wantSource    string
+		wantFields    []Field
+	}{
+		{
+			name:          "CEF with extension time",
+			input:         `CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1 dst=2.1.2.2 rt=1727692245123 msg=Detected a threat. No action needed`,
+			wantFormat:    FormatCEF,
+			wantLevel:     LevelCritical,
+			wantTimestamp: time.Date(2024, 9, 30, 10, 30, 45, 123000000, time.UTC),
+			wantMessage:   "worm successfully stopped",
+			wantSource:    "threatmanager",
+			wantFields: []Field{
+				{"device_vendor", "Security"},
+				{"device_version", "1.0"},
+				{"signature_id", "100"},
+				{"src", "10.0.0.1"},
+				{"dst", "2.1.2.2"},
+				{"msg", "Detected a threat. No action needed"},
+			},
+		},
+		{
+			name:          "CEF after a syslog header, with escapes",
+			input:         `<134>Sep 30 10:30:45 fw-1 CEF:0|Acme|Fire\|wall|2.3|scan|Port scan|Medium|act=blocked request=/a\=b cs1=line\nbreak`,
+			wantFormat:    FormatCEF,
+			wantLevel:     LevelWarn,
+			wantTimestamp: time.Date(2024, 9, 30, 10, 30, 45, 0, time.UTC),
+			wantMessage:   "Port scan",
+			wantSource:    "Fire|wall",
+			wantFields: []Field{
+				{"device_vendor", "Acme"},
+				{"device_version", "2.3"},
+				{"signature_id", "scan"},
+				{"act", "blocked"},
+				{"request", "/a=b"},
+				{"cs1", "line\nbreak"},
+			},
+		},
+		{
+			name:          "LEEF 1.0",
+			input:         "2024-09-30T10:30:45Z fw-1 LEEF:1.0|Acme|Firewall|2.3|PortScan|src=10.0.0.1\tsev=2\tproto=TCP",
+			wantFormat:    FormatLEEF,
+			wantLevel:     LevelInfo,
+			wantTimestamp: time.Date(2024, 9, 30, 10, 30, 45, 0, time.UTC),
+			wantMessage:   "PortScan",
+			wantSource:    "Firewall",
+			wantFields: []Field{
+				{"device_vendor", "Acme"},
+				{"device_version", "2.3"},
+				{"src", "10.0.0.1"},
+				{"proto", "TCP"},
+			},
+		},
+		{
+			name:          "LEEF 2.0 with a delimiter",
+			input:         "LEEF:2.0|Acme|IDS|4.1|LoginFailed|x5E|usrName=bob^sev=8^devTime=1727692245",
+			wantFormat:    FormatLEEF,
+			wantLevel:     LevelError,
+			wantTimestamp: time.Date(2024, 9, 30, 10, 30, 45, 0, time.UTC),
+			wantMessage:   "LoginFailed",
+			wantSource:    "IDS",
+			wantFields: []Field{
+				{"device_vendor", "Acme"},
+				{"device_version", "4.1"},
+				{"usrName", "bob"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := NewParser().Parse(tt.input)
+			if entry.Format != tt.wantFormat {
+				t.Errorf("Format = %v, want %v", entry.Format, tt.wantFormat)
+			}
+			if entry.Level != tt.wantLevel {
+				t.Errorf("Level = %v, want %v", entry.Level, tt.wantLevel)
+			}
+			if !entry.Timestamp.Equal(tt.wantTimestamp) {
+				t.Errorf("Timestamp = %v, want %v", entry.Timestamp, tt.wantTimestamp)
+			}
+			if entry.Message != tt.wantMessage {
+				t.Errorf("Message = %q, want %q", entry.Message, tt.wantMessage)
+			}
+			if entry.Source != tt.wantSource {
+				t.Errorf("Source = %q, want %q", entry.Source, tt.wantSource)
+			}
+			if !reflect.DeepEqual(entry.Fields, tt.wantFields) {
+				t.Errorf("Fields = %q, want %q", entry.Fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestParseSecurityEventRejectsText(t *testing.T) {
+	for _, line := range []string{
+		"2024-09-30 10:30:45 INFO loaded PDF:1.7 reader",
+		"2024-09-30 10:30:45 INFO XCEF:0|not|an|event",
+		"2024-09-30 10:30:45 WARN CEF:0|truncated|header",
+	} {
+		if entry := NewParser().Parse(line); entry.Format != FormatText {
+			t.Errorf("Parse(%q).Format = %v, want text", line, entry.Format)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// containerRecord is a record of a container runtime log, wrapping a line
+// written by the container or a part of it
+type containerRecord struct {
+	time    time.Time
+	stream  string
+	partial bool
+	log     string
+}
+
+// unwrapContainer reads a record of the Docker json-file format, as in
+// {"log":"message\n","stream":"stdout","time":"..."}, or of the CRI format
+// of containerd and CRI-O, as in "2024-09-30T10:30:45.123Z stdout F message"
+func unwrapContainer(line string) (containerRecord, bool) {
+	if strings.HasPrefix(line, `{"log":`) {
+		return unwrapDocker(line)
+	}
+	return unwrapCRI(line)
+}
+
+// unwrapDocker reads a record of the Docker json-file format. Lines longer
+// than the buffer of Docker are split in records whose log does not end
+// with a newline.
+func unwrapDocker(line string) (containerRecord, bool) {
+	var record struct {
+		Log    *string `json:"log"`
+		Stream string  `json:"stream"`
+		Time   string  `json:"time"`
+	}
+	if err := json.Unmarshal([]byte(line), &record); err != nil || record.Log == nil {
+		return containerRecord{}, false
+	}
+
+	log := *record.Log
+	partial := !strings.HasSuffix(log, "\n")
+	log = strings.TrimSuffix(strings.TrimSuffix(log, "\n"), "\r")
+
+	t, _ := parseTimestamp(record.Time)
+	return containerRecord{time: t, stream: record.Stream, partial: partial, log: log}, true
+}
+
+// unwrapCRI reads a record of the CRI format: a timestamp, the stream, a
+// tag, F for a full line or P for a partial one, and the log
+func unwrapCRI(line string) (containerRecord, bool) {
+	space := strings.IndexByte(line, ' ')
+	if space < 0 || !(strings.HasPrefix(line[space:], " stdout ") || strings.HasPrefix(line[space:], " stderr ")) {
+		return containerRecord{}, false
+	}
+	t, err := parseTimestamp(line[:space])
+	if err != nil {
+		return containerRecord{}, false
+	}
+
+	stream := line[space+1 : space+7]
+	rest := line[space+8:]
+	tag, log, _ := strings.Cut(rest, " ")
+	// Tags are colon-separated flags, the first one telling partial records
+	flag, _, _ := strings.Cut(tag, ":")
+	if flag != "F" && flag != "P" {
+		return containerRecord{}, false
+	}
+	return containerRecord{time: t, stream: stream, partial: flag == "P", log: log}, true
+}
+
+// ParseRecords parses a line of a container log split in records: partial
+// records, whose entries are Partial, and the record completing them. The
+// line they hold is parsed as any other, its timestamp being the one of the
+// runtime when it has none.
+func (p *Parser) ParseRecords(records []string) LogEntry {
+	var text strings.Builder
+	var last containerRecord
+	for _, line := range records {
+		record, ok := unwrapContainer(line)
+		if !ok {
+			// Not a record: taken as it is
+			record = containerRecord{log: line}
+		}
+		text.WriteString(record.log)
+		last = record
+	}
+
+	entry := p.parseRecord(last, text.String())
+	entry.Partial = false
+	return entry
+}
+
+// parseRecord parses the line text held by a container record. Its stream
+// is the first field of the entry.
+func (p *Parser) parseRecord(record containerRecord, text string) LogEntry {
+	entry := p.parseLine(text)
+	entry.Partial = record.partial
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = record.time
+	}
+	if record.stream != "" {
+		entry.Fields = append([]Field{{Key: "stream", Value: record.stream}}, entry.Fields...)
+	}
+	return entry
+}
+
+// IsPartialRecord reports whether line is a partial record of a container
+// log, to be completed by the next ones
+func IsPartialRecord(line string) bool {
+	record, ok := unwrapContainer(line)
+	return ok && record.partial
+}
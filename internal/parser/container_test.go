@@ -0,0 +1,117 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseContainerRecords(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		wantRaw       string
+		wantLevel     LogLevel
+		wantTimestamp time.Time
+		wantFormat    Format
+		wantFields    []Field
+		wantPartial   bool
+	}{
+		{
+			name:          "Docker json-file",
+			input:         `{"log":"2024-09-30 10:30:45 ERROR [db] query failed\n","stream":"stderr","time":"2024-09-30T10:30:45.987654321Z"}`,
+			wantRaw:       "2024-09-30 10:30:45 ERROR [db] query failed",
+			wantLevel:     LevelError,
+			wantTimestamp: time.Date(2024, 9, 30, 10, 30, 45, 0, time.UTC),
+			wantFields:    []Field{{"stream", "stderr"}},
+		},
+		{
+			name:          "Docker record without timestamp in the line",
+			input:         `{"log":"WARN disk almost full\r\n","stream":"stdout","time":"2024-09-30T10:30:45.5Z"}`,
+			wantRaw:       "WARN disk almost full",
+			wantLevel:     LevelWarn,
+			wantTimestamp: time.Date(2024, 9, 30, 10, 30, 45, 500000000, time.UTC),
+			wantFields:    []Field{{"stream", "stdout"}},
+		},
+		{
+			name:          "Docker partial record",
+			input:         `{"log":"INFO the start of a long line","stream":"stdout","time":"2024-09-30T10:30:45Z"}`,
+			wantRaw:       "INFO the start of a long line",
+			wantLevel:     LevelInfo,
+			wantTimestamp: time.Date(2024, 9, 30, 10, 30, 45, 0, time.UTC),
+			wantFields:    []Field{{"stream", "stdout"}},
+			wantPartial:   true,
+		},
+		{
+			name:          "CRI full record holding JSON",
+			input:         `2024-09-30T10:30:45.123456789Z stdout F {"level":"info","msg":"served","status":200}`,
+			wantRaw:       `{"level":"info","msg":"served","status":200}`,
+			wantLevel:     LevelInfo,
+			wantTimestamp: time.Date(2024, 9, 30, 10, 30, 45, 123456789, time.UTC),
+			wantFormat:    FormatJSON,
+			wantFields:    []Field{{"stream", "stdout"}, {"status", "200"}},
+		},
+		{
+			name:          "CRI partial record",
+			input:         "2024-09-30T10:30:45Z stderr P panic: runtime error",
+			wantRaw:       "panic: runtime error",
+			wantLevel:     LevelFatal,
+			wantTimestamp: time.Date(2024, 9, 30, 10, 30, 45, 0, time.UTC),
+			wantFields:    []Field{{"stream", "stderr"}},
+			wantPartial:   true,
+		},
+		{
+			name:          "Not a CRI record",
+			input:         "2024-09-30T10:30:45Z stdout X message",
+			wantRaw:       "2024-09-30T10:30:45Z stdout X message",
+			wantLevel:     LevelUnknown,
+			wantTimestamp: time.Date(2024, 9, 30, 10, 30, 45, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := NewParser().Parse(tt.input)
+			if entry.Raw != tt.wantRaw {
+				t.Errorf("Raw = %q, want %q", entry.Raw, tt.wantRaw)
+			}
+			if entry.Level != tt.wantLevel {
+				t.Errorf("Level = %v, want %v", entry.Level, tt.wantLevel)
+			}
+			if !entry.Timestamp.Equal(tt.wantTimestamp) {
+				t.Errorf("Timestamp = %v, want %v", entry.Timestamp, tt.wantTimestamp)
+			}
+			if entry.Format != tt.wantFormat {
+				t.Errorf("Format = %v, want %v", entry.Format, tt.wantFormat)
+			}
+			if !reflect.DeepEqual(entry.Fields, tt.wantFields) {
+				t.Errorf("Fields = %v, want %v", entry.Fields, tt.wantFields)
+			}
+			if entry.Partial != tt.wantPartial {
+				t.Errorf("Partial = %v, want %v", entry.Partial, tt.wantPartial)
+			}
+			if IsPartialRecord(tt.input) != tt.wantPartial {
+				t.Errorf("IsPartialRecord() = %v, want %v", !tt.wantPartial, tt.wantPartial)
+			}
+		})
+	}
+}
+
+func TestParseRecords(t *testing.T) {
+	records := []string{
+		`2024-09-30T10:30:45Z stdout P {"level":"error","msg":"request`,
+		`2024-09-30T10:30:45Z stdout P  failed",`,
+		`2024-09-30T10:30:46Z stdout F "path":"/users"}`,
+	}
+
+	entry := NewParser().ParseRecords(records)
+	if want := `{"level":"error","msg":"request failed","path":"/users"}`; entry.Raw != want {
+		t.Errorf("Raw = %q, want %q", entry.Raw, want)
+	}
+	if entry.Level != LevelError || entry.Message != "request failed" || entry.Partial {
+		t.Errorf("entry = %+v, want a complete ERROR entry", entry)
+	}
+	if want := []Field{{"stream", "stdout"}, {"path", "/users"}}; !reflect.DeepEqual(entry.Fields, want) {
+		t.Errorf("Fields = %v, want %v", entry.Fields, want)
+	}
+}
@@ -0,0 +1,37 @@
+package parser
+
+import "strings"
+
+var gelfDialect = jsonDialect{format: FormatGELF, role: gelfRole, finish: trimAdditionalFields}
+
+// isGELF reports whether a JSON object is a GELF message, which always holds
+// its short message
+func isGELF(object string) bool {
+	return strings.Contains(object, `"short_message":`)
+}
+
+// gelfRole returns the part of an entry held by the GELF fields named key.
+// Timestamps are Unix times in seconds and levels syslog severities.
+func gelfRole(key string) fieldRole {
+	switch key {
+	case "timestamp":
+		return roleTime
+	case "level":
+		return roleLevel
+	case "short_message":
+		return roleMessage
+	case "host":
+		return roleSource
+	}
+	return roleNone
+}
+
+// trimAdditionalFields names the additional fields of a GELF message, whose
+// keys start with an underscore, without it
+func trimAdditionalFields(entry *LogEntry) {
+	for i, field := range entry.Fields {
+		if len(field.Key) > 1 && field.Key[0] == '_' {
+			entry.Fields[i].Key = field.Key[1:]
+		}
+	}
+}
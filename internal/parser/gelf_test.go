@@ -0,0 +1,36 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseGELF(t *testing.T) {
+	line := `{"version":"1.1","host":"web-1","short_message":"upstream timed out","full_message":"upstream timed out\nafter 30s","timestamp":1727692245.123,"level":3,"_user_id":9001,"_path":"/users","_":"kept"}`
+
+	entry := NewParser().Parse(line)
+	if entry.Format != FormatGELF {
+		t.Errorf("Format = %v, want gelf", entry.Format)
+	}
+	if entry.Level != LevelError {
+		t.Errorf("Level = %v, want ERROR", entry.Level)
+	}
+	if want := time.Date(2024, 9, 30, 10, 30, 45, 123000000, time.UTC); !entry.Timestamp.Equal(want) {
+		t.Errorf("Timestamp = %v, want %v", entry.Timestamp, want)
+	}
+	if entry.Message != "upstream timed out" || entry.Source != "web-1" {
+		t.Errorf("Message, Source = %q, %q, want upstream timed out, web-1", entry.Message, entry.Source)
+	}
+
+	want := []Field{
+		{"version", "1.1"},
+		{"full_message", "upstream timed out\nafter 30s"},
+		{"user_id", "9001"},
+		{"path", "/users"},
+		{"_", "kept"},
+	}
+	if !reflect.DeepEqual(entry.Fields, want) {
+		t.Errorf("Fields = %q, want %q", entry.Fields, want)
+	}
+}
@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+var journalDialect = jsonDialect{format: FormatJournal, role: journalRole, finish: setJournalSource}
+
+// isJournal reports whether a JSON object is a journald entry, which always
+// holds its realtime timestamp
+func isJournal(object string) bool {
+	return strings.Contains(object, `"__REALTIME_TIMESTAMP":`)
+}
+
+// journalRole returns the part of an entry held by the journald fields named
+// key. The systemd unit only stands for the source when the entry has no
+// syslog identifier: see setJournalSource.
+func journalRole(key string) fieldRole {
+	switch key {
+	case "__REALTIME_TIMESTAMP":
+		return roleTime
+	case "PRIORITY":
+		return roleLevel
+	case "MESSAGE":
+		return roleMessage
+	case "SYSLOG_IDENTIFIER":
+		return roleSource
+	}
+	return roleNone
+}
+
+// setJournalSource makes the systemd unit of a journald entry its source when
+// it has no syslog identifier
+func setJournalSource(entry *LogEntry) {
+	if entry.Source != "" {
+		return
+	}
+	for _, field := range entry.Fields {
+		if field.Key == "_SYSTEMD_UNIT" && field.Value != "" {
+			entry.Source = field.Value
+			return
+		}
+	}
+}
+
+// journalBytes decodes a binary journald value, which journalctl -o json
+// prints as an array of bytes
+func journalBytes(value string) (string, bool) {
+	var bytes []int
+	if err := json.Unmarshal([]byte(value), &bytes); err != nil {
+		return "", false
+	}
+
+	text := make([]byte, len(bytes))
+	for i, b := range bytes {
+		if b < 0 || b > 255 {
+			return "", false
+		}
+		text[i] = byte(b)
+	}
+	return string(text), true
+}
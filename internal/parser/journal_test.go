@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseJournal(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		wantLevel     LogLevel
+		wantTimestamp time.Time
+		wantMessage   string
+		wantSource    string
+		wantFields    []Field
+	}{
+		{
+			name:          "Syslog identifier",
+			input:         `{"__CURSOR":"s=6f1c;i=1a2b","__REALTIME_TIMESTAMP":"1727692245123456","PRIORITY":"6","SYSLOG_IDENTIFIER":"sshd","_PID":"812","_SYSTEMD_UNIT":"ssh.service","MESSAGE":"Accepted publickey for deploy"}`,
+			wantLevel:     LevelInfo,
+			wantTimestamp: time.Date(2024, 9, 30, 10, 30, 45, 123456000, time.UTC),
+			wantMessage:   "Accepted publickey for deploy",
+			wantSource:    "sshd",
+			wantFields:    []Field{{"__CURSOR", "s=6f1c;i=1a2b"}, {"_PID", "812"}, {"_SYSTEMD_UNIT", "ssh.service"}},
+		},
+		{
+			name:          "Systemd unit and binary values",
+			input:         `{"__REALTIME_TIMESTAMP":"1727692246500000","PRIORITY":"3","_SYSTEMD_UNIT":"backup.service","MESSAGE":[100,105,115,107,10,102,117,108,108],"RAW":[255,0,1],"TAGS":["a","b"]}`,
+			wantLevel:     LevelError,
+			wantTimestamp: time.Date(2024, 9, 30, 10, 30, 46, 500000000, time.UTC),
+			wantMessage:   "disk\nfull",
+			wantSource:    "backup.service",
+			wantFields:    []Field{{"_SYSTEMD_UNIT", "backup.service"}, {"RAW", "\xff\x00\x01"}, {"TAGS", `["a","b"]`}},
+		},
+		{
+			name:          "Emergency",
+			input:         `{"__REALTIME_TIMESTAMP":"1727692247000000","PRIORITY":"0","MESSAGE":"kernel panic"}`,
+			wantLevel:     LevelFatal,
+			wantTimestamp: time.Date(2024, 9, 30, 10, 30, 47, 0, time.UTC),
+			wantMessage:   "kernel panic",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := NewParser().Parse(tt.input)
+			if entry.Format != FormatJournal {
+				t.Errorf("Format = %v, want journal", entry.Format)
+			}
+			if entry.Level != tt.wantLevel {
+				t.Errorf("Level = %v, want %v", entry.Level, tt.wantLevel)
+			}
+			if !entry.Timestamp.Equal(tt.wantTimestamp) {
+				t.Errorf("Timestamp = %v, want %v", entry.Timestamp, tt.wantTimestamp)
+			}
+			if entry.Message != tt.wantMessage {
+				t.Errorf("Message = %q, want %q", entry.Message, tt.wantMessage)
+			}
+			if entry.Source != tt.wantSource {
+				t.Errorf("Source = %q, want %q", entry.Source, tt.wantSource)
+			}
+			if !reflect.DeepEqual(entry.Fields, tt.wantFields) {
+				t.Errorf("Fields = %q, want %q", entry.Fields, tt.wantFields)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// EmbeddedJSON returns the spans of the JSON objects embedded in a line, such
+// as the body in `request failed body={"code":500}`. Arrays are only reported
+// when they hold objects or arrays, so that "[main]" or "[200]" are left alone.
+func EmbeddedJSON(line string) []Span {
+	if !strings.ContainsAny(line, "{[") {
+		return nil
+	}
+
+	var spans []Span
+	invalid := -1 // the syntax error of the last invalid candidate
+	for _, pair := range bracketPairs(line) {
+		// Brackets within a reported object are part of it
+		if len(spans) > 0 && pair.Start < spans[len(spans)-1].End {
+			continue
+		}
+		// A value around the syntax error of an enclosing candidate is
+		// invalid at the same byte, so that nested brackets are not all
+		// validated again
+		if pair.Start < invalid && invalid < pair.End {
+			continue
+		}
+
+		candidate := line[pair.Start:pair.End]
+		if candidate[0] == '[' && !strings.ContainsAny(candidate[1:], "{[") {
+			continue
+		}
+		var value json.RawMessage
+		err := json.Unmarshal([]byte(candidate), &value)
+		if err == nil {
+			spans = append(spans, pair)
+		} else if syntax, ok := err.(*json.SyntaxError); ok {
+			invalid = pair.Start + int(syntax.Offset) - 1
+		}
+	}
+	return spans
+}
+
+// bracketPairs returns the spans of the bracketed texts of line, sorted by
+// start, in a single pass. Like closingBracket, it skips brackets within
+// strings, which only open within brackets; brackets never closed have no span.
+func bracketPairs(line string) []Span {
+	var pairs, open []Span
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '"':
+			if len(open) == 0 {
+				continue
+			}
+			// Skip the string, with its escaped quotes
+			for i++; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' {
+					i++
+				}
+			}
+		case '{', '[':
+			open = append(open, Span{Start: i})
+		case '}', ']':
+			if len(open) == 0 {
+				continue
+			}
+			pair := open[len(open)-1]
+			open = open[:len(open)-1]
+			pair.End = i + 1
+			pairs = append(pairs, pair)
+		}
+	}
+
+	slices.SortFunc(pairs, func(a, b Span) int { return a.Start - b.Start })
+	return pairs
+}
+
+// closingBracket returns the end of the bracketed text opening at line[start],
+// skipping brackets within strings, or -1 when it is not closed
+func closingBracket(line string, start int) int {
+	depth := 0
+	for i := start; i < len(line); i++ {
+		switch line[i] {
+		case '"':
+			// Skip the string, with its escaped quotes
+			for i++; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' {
+					i++
+				}
+			}
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
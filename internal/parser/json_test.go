@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmbeddedJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "Object at the end",
+			input: `ERROR request failed body={"code":500,"errors":["timeout"]}`,
+			want:  []string{`{"code":500,"errors":["timeout"]}`},
+		},
+		{
+			name:  "Brackets inside strings",
+			input: `payload={"msg":"a } and ] in text","n":1} done`,
+			want:  []string{`{"msg":"a } and ] in text","n":1}`},
+		},
+		{
+			name:  "Several blobs and an array of objects",
+			input: `old={"a":1} new=[{"a":2},{"a":3}]`,
+			want:  []string{`{"a":1}`, `[{"a":2},{"a":3}]`},
+		},
+		{
+			name:  "Bracketed words and scalar arrays are not expanded",
+			input: `[main] INFO codes [200, 404] {not json}`,
+		},
+		{
+			name:  "Unclosed object",
+			input: `body={"code":500`,
+		},
+		{
+			name:  "Object within brackets",
+			input: `[worker {"id":1}] [{"id":[2]} x] started`,
+			want:  []string{`{"id":1}`, `{"id":[2]}`},
+		},
+		{
+			name:  "Object within a truncated one",
+			input: `body={"code":500,"detail":{"reason":"timeout"},"trace":[`,
+			want:  []string{`{"reason":"timeout"}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, span := range EmbeddedJSON(tt.input) {
+				got = append(got, tt.input[span.Start:span.End])
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EmbeddedJSON() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbeddedJSONLongLines(t *testing.T) {
+	// Each unclosed bracket used to be followed to the end of the line, and
+	// each invalid nested one validated again
+	for _, input := range []string{
+		strings.Repeat("[", 100_000),
+		strings.Repeat("{", 100_000),
+		strings.Repeat("[", 50_000) + "x" + strings.Repeat("]", 50_000),
+		`body=` + strings.Repeat(`{"a":[1,`, 20_000) + `... [truncated 5000 bytes]`,
+	} {
+		start := time.Now()
+		if spans := EmbeddedJSON(input); len(spans) != 0 {
+			t.Errorf("EmbeddedJSON() on invalid JSON = %v, want none", spans)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("EmbeddedJSON() on a %d-byte line took %v", len(input), elapsed)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// levelAlias is a name a level is written as
+type levelAlias struct {
+	level LogLevel
+	// anyCase aliases are recognized in text in any case. The others, being
+	// common words such as "notice" or "alert", only when written in upper
+	// case; level fields take them in any case.
+	anyCase bool
+}
+
+// maxAliasLength is the length of the longest alias lookupAlias can match
+const maxAliasLength = 32
+
+var (
+	// levelAliases maps upper-case names to levels
+	levelAliases = map[string]levelAlias{}
+
+	// maxLevelLength, levelLengths and levelInitials rule out most words
+	// before looking them up in levelAliases: their length must be one of
+	// an alias, and their first letter too
+	maxLevelLength int
+	levelLengths   uint64
+	levelInitials  [256]bool
+
+	// numericLevels maps numeric values of level fields to levels, overriding
+	// the pino/bunyan and syslog scales
+	numericLevels = map[int]LogLevel{}
+)
+
+func init() {
+	for _, name := range []string{"TRACE", "DEBUG", "INFO", "WARN", "WARNING", "ERROR", "ERR", "FATAL", "PANIC"} {
+		level, _ := builtinLevel(name)
+		registerAlias(name, levelAlias{level: level, anyCase: true})
+	}
+	for name, level := range map[string]LogLevel{
+		"FINEST":        LevelTrace,
+		"FINER":         LevelTrace,
+		"VERBOSE":       LevelTrace,
+		"FINE":          LevelDebug,
+		"DBG":           LevelDebug,
+		"INFORMATION":   LevelInfo,
+		"INFORMATIONAL": LevelInfo,
+		"NOTICE":        LevelNotice,
+		"WRN":           LevelWarn,
+		"SEVERE":        LevelError,
+		"CRIT":          LevelCritical,
+		"CRITICAL":      LevelCritical,
+		"ALERT":         LevelFatal,
+		"EMERG":         LevelFatal,
+		"EMERGENCY":     LevelFatal,
+	} {
+		registerAlias(name, levelAlias{level: level})
+	}
+}
+
+// builtinLevel returns the level of one of the core names
+func builtinLevel(name string) (LogLevel, bool) {
+	switch name {
+	case "TRACE":
+		return LevelTrace, true
+	case "DEBUG":
+		return LevelDebug, true
+	case "INFO":
+		return LevelInfo, true
+	case "NOTICE":
+		return LevelNotice, true
+	case "WARN", "WARNING":
+		return LevelWarn, true
+	case "ERROR", "ERR":
+		return LevelError, true
+	case "CRITICAL":
+		return LevelCritical, true
+	case "FATAL", "PANIC":
+		return LevelFatal, true
+	}
+	return "", false
+}
+
+func registerAlias(name string, alias levelAlias) {
+	levelAliases[name] = alias
+	maxLevelLength = max(maxLevelLength, len(name))
+	levelLengths |= 1 << min(len(name), 63)
+	levelInitials[name[0]] = true
+	levelInitials[name[0]|0x20] = true
+}
+
+// ParseLevel returns the level named by name, in any case, as given by users
+func ParseLevel(name string) (LogLevel, error) {
+	if level, ok := builtinLevel(strings.ToUpper(name)); ok {
+		return level, nil
+	}
+	return "", fmt.Errorf("unknown level %q: use trace, debug, info, notice, warn, error, critical or fatal", name)
+}
+
+// RegisterLevel makes name an alias of level. A number maps this value of
+// numeric level fields. Like the built-in aliases that are common words,
+// user aliases are recognized in text when written in upper case only. It
+// must not be called while lines are being parsed.
+func RegisterLevel(name string, level LogLevel) error {
+	if n, err := strconv.Atoi(name); err == nil {
+		numericLevels[n] = level
+		return nil
+	}
+	if name == "" || strings.IndexFunc(name, func(r rune) bool { return r > 127 || !isWordByte(byte(r)) }) >= 0 {
+		return fmt.Errorf("invalid level alias %q: use letters, digits and underscores", name)
+	}
+	if len(name) > maxAliasLength {
+		return fmt.Errorf("invalid level alias %q: longer than %d characters", name, maxAliasLength)
+	}
+	registerAlias(strings.ToUpper(name), levelAlias{level: level})
+	return nil
+}
+
+// lookupLevel returns the level named by a word of text
+func lookupLevel(word string) (LogLevel, bool) {
+	return lookupAlias(word, false)
+}
+
+// lookupLevelValue returns the level named by the value of a level field:
+// an alias in any case, or a number
+func lookupLevelValue(value string) (LogLevel, bool) {
+	if level, ok := lookupAlias(value, true); ok {
+		return level, true
+	}
+	if n, err := strconv.Atoi(value); err == nil {
+		return numericLevel(n)
+	}
+	return "", false
+}
+
+// lookupAlias returns the level of an alias. Words in mixed or lower case
+// only match anyCase aliases, unless anyCase is set.
+func lookupAlias(word string, anyCase bool) (LogLevel, bool) {
+	if len(word) == 0 || len(word) > maxLevelLength || len(word) > maxAliasLength ||
+		levelLengths&(1<<len(word)) == 0 || !levelInitials[word[0]] {
+		return "", false
+	}
+
+	// Upper-case into a stack buffer, so that the lookup does not allocate
+	var buf [maxAliasLength]byte
+	upper := true
+	for i := 0; i < len(word); i++ {
+		c := word[i]
+		if c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+			upper = false
+		}
+		buf[i] = c
+	}
+
+	alias, ok := levelAliases[string(buf[:len(word)])]
+	if !ok || !(alias.anyCase || anyCase || upper) {
+		return "", false
+	}
+	return alias.level, true
+}
+
+// numericLevel maps a numeric level: 0 to 7 on the syslog scale, from
+// emergency to debug, and 10 to 60 on the pino and bunyan one, from trace to
+// fatal, custom levels in between counting as the level below them
+func numericLevel(n int) (LogLevel, bool) {
+	if level, ok := numericLevels[n]; ok {
+		return level, true
+	}
+
+	switch {
+	case n < 0:
+		return "", false
+	case n <= 7:
+		return [...]LogLevel{LevelFatal, LevelFatal, LevelCritical, LevelError, LevelWarn, LevelNotice, LevelInfo, LevelDebug}[n], true
+	case n < 10:
+		return "", false
+	case n < 20:
+		return LevelTrace, true
+	case n < 30:
+		return LevelDebug, true
+	case n < 40:
+		return LevelInfo, true
+	case n < 50:
+		return LevelWarn, true
+	case n < 60:
+		return LevelError, true
+	default:
+		return LevelFatal, true
+	}
+}
+
+// Scores of level words by position, from the most to the least likely
+const (
+	// markedScore is a level in brackets, as in [ERROR] or <warn>, or the
+	// value of a level key
+	markedScore = 1.0
+	// headerScore is a level starting the line or following the timestamp
+	headerScore = 0.9
+	// nearHeaderScore is a level within the first tokens after the
+	// timestamp, in upper case or followed by a colon, as in
+	// "host app[42]: ERROR ..."
+	nearHeaderScore = 0.6
+	// textScore is a level word in upper case further in the line
+	textScore = 0.3
+	// wordScore is any other level word, likely part of the message
+	wordScore = 0.2
+)
+
+// levelKeys are the keys whose values are levels in text lines
+var levelKeys = []string{"level", "lvl", "severity", "loglevel"}
+
+// levelScore scores the level word at line[start:end]. from is the start of
+// the header: the end of the timestamp when it precedes the word. Words that
+// are part of an identifier, a path or the value of another key score 0.
+// tokens counts the tokens of the header across the words of a line.
+func levelScore(line string, start, end, from int, tokens *tokenCounter) float64 {
+	var before, after byte
+	if start > 0 {
+		before = line[start-1]
+	}
+	if end < len(line) {
+		after = line[end]
+	}
+	attached := end+1 < len(line) && isWordByte(line[end+1])
+
+	switch {
+	case before == '[' && after == ']', before == '<' && after == '>', before == '(' && after == ')':
+		return markedScore
+	case before == '=':
+		for _, key := range levelKeys {
+			if keyStart := start - 1 - len(key); keyStart >= 0 && strings.EqualFold(line[keyStart:start-1], key) &&
+				(keyStart == 0 || !isKeyByte(line[keyStart-1])) {
+				return markedScore
+			}
+		}
+		return 0
+	case before == '/' || before == '.' || before == '-' || before == '@':
+		return 0
+	case (after == '/' || after == '.' || after == '-' || after == '@' || after == '=') && attached:
+		return 0
+	}
+
+	upper := true
+	for i := start; i < end; i++ {
+		if line[i] >= 'a' && line[i] <= 'z' {
+			upper = false
+			break
+		}
+	}
+
+	switch tokens := tokens.count(line, from, start); {
+	case tokens == 0:
+		return headerScore
+	case tokens <= 3 && (upper || after == ':'):
+		return nearHeaderScore
+	case upper:
+		return textScore
+	default:
+		return wordScore
+	}
+}
+
+// tokenCounter counts the space-separated tokens holding a word, so that
+// separators such as "]" or "|" do not count. It goes on from the previous
+// count as long as the header start is the same, so that scoring all the
+// level words of a long line stays linear.
+type tokenCounter struct {
+	from, pos, tokens int
+	word              bool
+}
+
+// count returns the number of tokens of line[from:end]
+func (c *tokenCounter) count(line string, from, end int) int {
+	if from != c.from || end < c.pos {
+		*c = tokenCounter{from: from, pos: from}
+	}
+	for ; c.pos < end; c.pos++ {
+		if isSpace(line[c.pos]) {
+			c.word = false
+		} else if isWordByte(line[c.pos]) && !c.word {
+			c.word = true
+			c.tokens++
+		}
+	}
+	return c.tokens
+}
@@ -0,0 +1,166 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLevelAliases(t *testing.T) {
+	tests := []struct {
+		input string
+		want  LogLevel
+	}{
+		{"2024-09-30 10:30:45 CRITICAL disk full", LevelCritical},
+		{"2024-09-30 10:30:45 NOTICE rotated keys", LevelNotice},
+		{"2024-09-30 10:30:45 SEVERE out of memory", LevelError},
+		{"Sep 30 10:30:45 host kernel: EMERG panic", LevelFatal},
+		{"FINE loading class", LevelDebug},
+		{"VERBOSE cache state", LevelTrace},
+		{"error: connection reset", LevelError},
+		// Common words are levels in upper case only
+		{"Please notice the alert below", LevelUnknown},
+		{"critical path computed", LevelUnknown},
+		{`{"level":"notice","msg":"x"}`, LevelNotice},
+		{`{"level":"Critical","msg":"x"}`, LevelCritical},
+	}
+	for _, tt := range tests {
+		if got := ParseLogLine(tt.input).Level; got != tt.want {
+			t.Errorf("ParseLogLine(%q) level = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestNumericLevels(t *testing.T) {
+	tests := []struct {
+		value string
+		want  LogLevel
+	}{
+		{"10", LevelTrace},
+		{"20", LevelDebug},
+		{"30", LevelInfo},
+		{"35", LevelInfo},
+		{"40", LevelWarn},
+		{"50", LevelError},
+		{"60", LevelFatal},
+		{"0", LevelFatal},
+		{"2", LevelCritical},
+		{"3", LevelError},
+		{"5", LevelNotice},
+		{"7", LevelDebug},
+		{"8", ""},
+		{"-1", ""},
+	}
+	for _, tt := range tests {
+		if got, _ := lookupLevelValue(tt.value); got != tt.want {
+			t.Errorf("lookupLevelValue(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterLevel(t *testing.T) {
+	defer func() {
+		delete(levelAliases, "SUCCESS")
+		delete(levelAliases, "OK")
+		delete(numericLevels, 35)
+	}()
+
+	if err := RegisterLevel("success", LevelInfo); err != nil {
+		t.Fatalf("RegisterLevel() unexpected error: %v", err)
+	}
+	if err := RegisterLevel("35", LevelWarn); err != nil {
+		t.Fatalf("RegisterLevel() unexpected error: %v", err)
+	}
+	if err := RegisterLevel("ok", LevelInfo); err != nil {
+		t.Fatalf("RegisterLevel() unexpected error: %v", err)
+	}
+	if err := RegisterLevel("not a word", LevelInfo); err == nil {
+		t.Error("RegisterLevel() with spaces returned no error")
+	}
+	if err := RegisterLevel(strings.Repeat("LOUD", 10), LevelInfo); err == nil {
+		t.Error("RegisterLevel() with a 40-character alias returned no error")
+	}
+
+	if got := ParseLogLine("2024-09-30 10:30:45 SUCCESS deployed").Level; got != LevelInfo {
+		t.Errorf("user alias level = %v, want INFO", got)
+	}
+	if got := ParseLogLine("2024-09-30 10:30:45 OK health check").Level; got != LevelInfo {
+		t.Errorf("two-letter user alias level = %v, want INFO", got)
+	}
+	if got := ParseLogLine(`{"level":"ok","msg":"x"}`).Level; got != LevelInfo {
+		t.Errorf("two-letter user alias field level = %v, want INFO", got)
+	}
+	if got := ParseLogLine(`{"level":35,"msg":"x"}`).Level; got != LevelWarn {
+		t.Errorf("user numeric level = %v, want WARN", got)
+	}
+
+	if level, err := ParseLevel("Critical"); err != nil || level != LevelCritical {
+		t.Errorf("ParseLevel(\"Critical\") = %v, %v", level, err)
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Error("ParseLevel(\"loud\") returned no error")
+	}
+}
+
+func TestLevelScoring(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		wantLevel      LogLevel
+		wantWord       string
+		wantConfidence float64
+	}{
+		{"First token", "INFO retrying after error", LevelInfo, "INFO", headerScore},
+		{"After the timestamp", "2024-09-30 10:30:45 warn disk almost full", LevelWarn, "warn", headerScore},
+		{"After a bracketed timestamp", "[2024-09-30 10:30:45] | DEBUG cache miss", LevelDebug, "DEBUG", headerScore},
+		{"Bracketed", "2024-09-30 10:30:45 [main] [error] failed", LevelError, "error", markedScore},
+		{"Level key", "ts=now component=api level=warn", LevelWarn, "warn", markedScore},
+		{"Identifier is skipped", "[req 1] user=error-handler INFO started", LevelInfo, "INFO", nearHeaderScore},
+		{"Path is skipped", "GET /debug/pprof served by 10.0.0.1 INFO ok", LevelInfo, "INFO", textScore},
+		{"Syslog program", "Sep 30 10:30:45 host app[42]: error: disk full", LevelError, "error", nearHeaderScore},
+		{"Level later in upper case", "request from 10.0.0.1 took 5ms ERROR timeout", LevelError, "ERROR", textScore},
+		{"Level word in text", "the request failed with an error", LevelError, "error", wordScore},
+		{"Only identifiers", "GET /debug/vars user=info-bot", LevelUnknown, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := ParseLogLine(tt.input)
+			if entry.Level != tt.wantLevel {
+				t.Errorf("level = %v, want %v", entry.Level, tt.wantLevel)
+			}
+			if got := tt.input[entry.LevelSpan.Start:entry.LevelSpan.End]; got != tt.wantWord {
+				t.Errorf("level span covers %q, want %q", got, tt.wantWord)
+			}
+			if entry.LevelConfidence != tt.wantConfidence {
+				t.Errorf("confidence = %v, want %v", entry.LevelConfidence, tt.wantConfidence)
+			}
+		})
+	}
+}
+
+func TestLevelScoreLongLine(t *testing.T) {
+	// Without a timestamp the whole line is searched for a level: scoring
+	// each word must not count the tokens before it again
+	tests := []struct {
+		name  string
+		input string
+		want  LogLevel
+	}{
+		{"Level words", "request " + strings.Repeat("error ", 100_000), LevelError},
+		{"Marked level at the end", strings.Repeat("a ", 500_000) + "[WARN] done", LevelWarn},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			entry := ParseLogLine(tt.input)
+			if entry.Level != tt.want {
+				t.Errorf("level = %v, want %v", entry.Level, tt.want)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("parsing a %d-byte line took %v", len(tt.input), elapsed)
+			}
+		})
+	}
+}
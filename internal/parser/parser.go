@@ -2,7 +2,6 @@ package parser
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
 	"time"
 )
@@ -11,13 +10,15 @@ import (
 type LogLevel string
 
 const (
-	LevelTrace   LogLevel = "TRACE"
-	LevelDebug   LogLevel = "DEBUG"
-	LevelInfo    LogLevel = "INFO"
-	LevelWarn    LogLevel = "WARN"
-	LevelError   LogLevel = "ERROR"
-	LevelFatal   LogLevel = "FATAL"
-	LevelUnknown LogLevel = "UNKNOWN"
+	LevelTrace    LogLevel = "TRACE"
+	LevelDebug    LogLevel = "DEBUG"
+	LevelInfo     LogLevel = "INFO"
+	LevelNotice   LogLevel = "NOTICE"
+	LevelWarn     LogLevel = "WARN"
+	LevelError    LogLevel = "ERROR"
+	LevelCritical LogLevel = "CRITICAL"
+	LevelFatal    LogLevel = "FATAL"
+	LevelUnknown  LogLevel = "UNKNOWN"
 )
 
 // LogEntry represents a parsed log line
@@ -27,95 +28,182 @@ type LogEntry struct {
 	Message   string
 	Source    string
 	Raw       string
+
+	// TimestampSpan, LevelSpan and SourceSpan locate the text of the
+	// timestamp, level and source in Raw. They are empty when not found.
+	TimestampSpan Span
+	LevelSpan     Span
+	SourceSpan    Span
+
+	// Partial is set for the partial records of container logs, which
+	// only hold the start of a line: see ParseRecords
+	Partial bool
+
+	// LevelConfidence tells how sure the parser is of the level, from 0 when
+	// none was found to 1 for a level field or a bracketed level
+	LevelConfidence float64
+
+	// Format is the format of the line. The fields of JSON and logfmt
+	// entries that are not the timestamp, level, message or source are
+	// kept in Fields, in order.
+	Format Format
+	Fields []Field
 }
 
-var (
-	// Common log patterns
-	timestampPatterns = []*regexp.Regexp{
-		regexp.MustCompile(`(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:\.\d{3})?(?:Z|[+-]\d{2}:\d{2})?)`),
-		regexp.MustCompile(`(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2})`),
-		regexp.MustCompile(`(\d{2}/\d{2}/\d{4} \d{2}:\d{2}:\d{2})`),
-		regexp.MustCompile(`(\w{3} \d{2} \d{2}:\d{2}:\d{2})`),
-	}
+// Span locates a part of a line, from Start to End excluded
+type Span struct {
+	Start int
+	End   int
+}
 
-	levelPattern = regexp.MustCompile(`(?i)\b(TRACE|DEBUG|INFO|WARN|WARNING|ERROR|ERR|FATAL|PANIC)\b`)
-)
+// IsEmpty reports whether the span covers no text
+func (s Span) IsEmpty() bool {
+	return s.End <= s.Start
+}
+
+// Parser parses the lines of one stream. It remembers the shape and position
+// of the previous timestamp and tries them first, since the lines of a log
+// usually share one layout. A Parser is not safe for concurrent use.
+type Parser struct {
+	shape  timestampShape
+	offset int
+}
+
+// NewParser returns a Parser for a new stream
+func NewParser() *Parser {
+	return &Parser{}
+}
 
 // ParseLogLine attempts to parse a log line and extract structured information
 func ParseLogLine(line string) LogEntry {
+	var p Parser
+	return p.Parse(line)
+}
+
+// Parse extracts the timestamp, level, source and message of a line in a
+// single pass, without allocating. The timestamp is the first one found, the
+// level the word naming one in the most likely position, as scored by
+// levelScore, and the source is found by findSource, as in "ERROR [main]
+// message". JSON and logfmt lines are read field by field instead.
+func (p *Parser) Parse(line string) LogEntry {
+	if record, ok := unwrapContainer(line); ok {
+		return p.parseRecord(record, record.log)
+	}
+	return p.parseLine(line)
+}
+
+// parseLine parses a line of any format but the container runtime ones
+func (p *Parser) parseLine(line string) LogEntry {
+	if entry, ok := parseStructured(line); ok {
+		return entry
+	}
+
 	entry := LogEntry{
 		Raw:     line,
 		Level:   LevelUnknown,
 		Message: line,
 	}
 
-	// Try to extract timestamp
-	for _, pattern := range timestampPatterns {
-		if matches := pattern.FindStringSubmatch(line); len(matches) > 1 {
-			if timestamp, err := parseTimestamp(matches[1]); err == nil {
-				entry.Timestamp = timestamp
-				break
-			}
+	// Try the cached layout before scanning the line
+	timeStart, timeEnd := -1, -1
+	if p.shape != shapeNone && p.offset < len(line) && (p.offset == 0 || !isWordByte(line[p.offset-1])) {
+		if t, end, ok := scanShape(line, p.offset, p.shape); ok {
+			entry.Timestamp = t
+			timeStart, timeEnd = p.offset, end
 		}
 	}
 
-	// Extract log level
-	if matches := levelPattern.FindStringSubmatch(line); len(matches) > 1 {
-		level := strings.ToUpper(matches[1])
-		switch level {
-		case "WARNING":
-			entry.Level = LevelWarn
-		case "ERR":
-			entry.Level = LevelError
-		case "PANIC":
-			entry.Level = LevelFatal
-		default:
-			entry.Level = LogLevel(level)
+	// Every level word is a candidate, scored by its position. The scan
+	// stops once a timestamp and a level in or near the header are found,
+	// since words further in the line are likely part of the message.
+	levelStart, levelEnd := -1, -1
+	var tokens tokenCounter
+	for i := 0; i < len(line) && (entry.LevelConfidence < nearHeaderScore || timeStart < 0); {
+		if i == timeStart {
+			i = timeEnd
+			continue
+		}
+
+		c := line[i]
+		if !isWordByte(c) {
+			i++
+			continue
 		}
-	}
 
-	// Extract message (everything after level, or full line if no level found)
-	if entry.Level != LevelUnknown {
-		// Find the original level text in the line to extract message after it
-		if matches := levelPattern.FindStringSubmatch(line); len(matches) > 1 {
-			originalLevel := matches[1]
-			if idx := strings.Index(line, originalLevel); idx != -1 {
-				start := idx + len(originalLevel)
-				if start < len(line) {
-					entry.Message = strings.TrimSpace(line[start:])
-				}
+		// i starts a word: it may start a timestamp or be a level
+		if timeStart < 0 {
+			t, shape, end, ok := scanTimestamp(line, i)
+			if !ok && i == 0 && isDigit(c) {
+				t, end, ok = scanEpoch(line, i)
+				shape = shapeEpoch
+			}
+			if ok {
+				entry.Timestamp = t
+				timeStart, timeEnd = i, end
+				p.shape, p.offset = shape, i
+				i = end
+				continue
+			}
+		}
+
+		start := i
+		for i < len(line) && isWordByte(line[i]) {
+			i++
+		}
+		if level, ok := lookupLevel(line[start:i]); ok {
+			from := 0
+			if timeStart >= 0 && timeEnd <= start {
+				from = timeEnd
+			}
+			if score := levelScore(line, start, i, from, &tokens); score > entry.LevelConfidence {
+				entry.Level, entry.LevelConfidence = level, score
+				levelStart, levelEnd = start, i
 			}
 		}
 	}
 
+	if levelStart >= 0 {
+		entry.LevelSpan = Span{levelStart, levelEnd}
+		// Extract message (everything after level, or full line if nothing follows)
+		if levelEnd < len(line) {
+			entry.Message = strings.TrimSpace(line[levelEnd:])
+		}
+	}
+
+	headerStart := 0
+	if timeStart >= 0 && (levelStart < 0 || timeEnd <= levelStart) {
+		headerStart = timeEnd
+	}
+	findSource(&entry, line, entry.LevelSpan, headerStart, timeStart >= 0 && p.shape == shapeSyslog)
+
+	if timeStart >= 0 {
+		entry.TimestampSpan = Span{timeStart, timeEnd}
+	}
 	return entry
 }
 
-// parseTimestamp tries to parse timestamp from string using common formats
-func parseTimestamp(timestampStr string) (time.Time, error) {
-	formats := []string{
-		time.RFC3339,
-		time.RFC3339Nano,
-		"2006-01-02 15:04:05",
-		"2006-01-02T15:04:05",
-		"2006-01-02T15:04:05.000",
-		"2006/01/02 15:04:05",
-		"01/02/2006 15:04:05",
-		"Jan 02 15:04:05",
-	}
+// isWordByte reports whether c is part of a word as matched by \w
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || isDigit(c) || c == '_'
+}
 
-	for _, format := range formats {
-		if t, err := time.Parse(format, timestampStr); err == nil {
+// parseTimestamp parses a whole string holding a timestamp of a known shape,
+// or a Unix time
+func parseTimestamp(timestampStr string) (time.Time, error) {
+	if timestampStr != "" {
+		if t, _, end, ok := scanTimestamp(timestampStr, 0); ok && end == len(timestampStr) {
+			return t, nil
+		}
+		if t, ok := parseEpoch(timestampStr); ok {
 			return t, nil
 		}
 	}
-
 	return time.Time{}, fmt.Errorf("unable to parse timestamp: %s", timestampStr)
 }
 
-// IsErrorLevel returns true if the log level indicates an error or fatal condition
+// IsErrorLevel returns true if the log level indicates an error, critical or fatal condition
 func (entry LogEntry) IsErrorLevel() bool {
-	return entry.Level == LevelError || entry.Level == LevelFatal
+	return entry.Level == LevelError || entry.Level == LevelCritical || entry.Level == LevelFatal
 }
 
 // IsWarningLevel returns true if the log level indicates a warning
@@ -123,7 +211,7 @@ func (entry LogEntry) IsWarningLevel() bool {
 	return entry.Level == LevelWarn
 }
 
-// IsInfoLevel returns true if the log level indicates info, debug, or trace
+// IsInfoLevel returns true if the log level indicates notice, info, debug, or trace
 func (entry LogEntry) IsInfoLevel() bool {
-	return entry.Level == LevelInfo || entry.Level == LevelDebug || entry.Level == LevelTrace
+	return entry.Level == LevelNotice || entry.Level == LevelInfo || entry.Level == LevelDebug || entry.Level == LevelTrace
 }
@@ -1,7 +1,10 @@
 package parser
 
 import (
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseLogLine(t *testing.T) {
@@ -296,10 +299,215 @@ func TestComplexLogFormats(t *testing.T) {
 	}
 }
 
+func TestParseTimestampValues(t *testing.T) {
+	originalNow := now
+	defer func() {
+		now = originalNow
+	}()
+	now = func() time.Time { return time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name  string
+		input string
+		want  time.Time
+	}{
+		{
+			name:  "Nanoseconds",
+			input: "2024-09-30T10:30:45.123456789Z",
+			want:  time.Date(2024, 9, 30, 10, 30, 45, 123456789, time.UTC),
+		},
+		{
+			name:  "Negative offset",
+			input: "2024-09-30 10:30:45-05:30",
+			want:  time.Date(2024, 9, 30, 16, 0, 45, 0, time.UTC),
+		},
+		{
+			name:  "Space padded syslog day",
+			input: "Sep  3 10:30:45",
+			want:  time.Date(2024, 9, 3, 10, 30, 45, 0, time.UTC),
+		},
+		{
+			name:  "Syslog stamp of the previous year",
+			input: "Dec 31 23:59:59",
+			want:  time.Date(2023, 12, 31, 23, 59, 59, 0, time.UTC),
+		},
+		{
+			name:  "Syslog leap day",
+			input: "Feb 29 12:00:00",
+			want:  time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "Microseconds after a comma",
+			input: "2024-09-30 10:30:45,123456",
+			want:  time.Date(2024, 9, 30, 10, 30, 45, 123456000, time.UTC),
+		},
+		{
+			name:  "Digits past the nanosecond",
+			input: "2024/09/30 10:30:45.1234567891",
+			want:  time.Date(2024, 9, 30, 10, 30, 45, 123456789, time.UTC),
+		},
+		{
+			name:  "Offset without colon",
+			input: "2024-09-30T10:30:45.5+0200",
+			want:  time.Date(2024, 9, 30, 8, 30, 45, 500000000, time.UTC),
+		},
+		{
+			name:  "Apache",
+			input: "30/Sep/2024:10:30:45 -0700",
+			want:  time.Date(2024, 9, 30, 17, 30, 45, 0, time.UTC),
+		},
+		{
+			name:  "Epoch seconds",
+			input: "1727692245",
+			want:  time.Date(2024, 9, 30, 10, 30, 45, 0, time.UTC),
+		},
+		{
+			name:  "Epoch seconds with fraction",
+			input: "1727692245.25",
+			want:  time.Date(2024, 9, 30, 10, 30, 45, 250000000, time.UTC),
+		},
+		{
+			name:  "Epoch milliseconds",
+			input: "1727692245123",
+			want:  time.Date(2024, 9, 30, 10, 30, 45, 123000000, time.UTC),
+		},
+		{
+			name:  "Epoch microseconds",
+			input: "1727692245123456",
+			want:  time.Date(2024, 9, 30, 10, 30, 45, 123456000, time.UTC),
+		},
+		{
+			name:  "Epoch nanoseconds",
+			input: "1727692245123456789",
+			want:  time.Date(2024, 9, 30, 10, 30, 45, 123456789, time.UTC),
+		},
+		{
+			name:  "Leap day",
+			input: "2024/02/29 00:00:00",
+			want:  time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTimestamp(tt.input)
+			if err != nil {
+				t.Fatalf("parseTimestamp() unexpected error: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseTimestamp() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"2023/02/29 00:00:00", "2024-13-01 10:30:45", "2024-09-30 24:00:00", "Sep 30 10:30", "30/Sep/2024:10:30:45", "12345", "99999999999999999999"} {
+		if _, err := parseTimestamp(invalid); err == nil {
+			t.Errorf("parseTimestamp(%q) expected error but got none", invalid)
+		}
+	}
+}
+
+func TestParserCachedLayout(t *testing.T) {
+	p := NewParser()
+
+	// The cached layout must not hide lines of another layout
+	lines := []string{
+		"2024-09-30T10:30:45Z INFO first",
+		"2024-09-30T10:30:46Z WARN second",
+		"[app] Sep 30 10:30:47 ERROR third",
+		"no timestamp here",
+		"2024-09-30T10:30:48Z DEBUG fourth",
+	}
+	want := []string{"10:30:45", "10:30:46", "10:30:47", "", "10:30:48"}
+
+	for i, line := range lines {
+		entry := p.Parse(line)
+		got := ""
+		if !entry.Timestamp.IsZero() {
+			got = entry.Timestamp.Format("15:04:05")
+		}
+		if got != want[i] {
+			t.Errorf("Parse(%q) timestamp = %q, want %q", line, got, want[i])
+		}
+		if !reflect.DeepEqual(entry, ParseLogLine(line)) {
+			t.Errorf("Parse(%q) = %+v, differs from ParseLogLine()", line, entry)
+		}
+	}
+}
+
+func TestParseAllocations(t *testing.T) {
+	p := NewParser()
+	line := "2024-09-30 10:30:45.123+02:00 ERROR [db] Connection refused"
+
+	if allocs := testing.AllocsPerRun(100, func() { p.Parse(line) }); allocs != 0 {
+		t.Errorf("Parse() allocated %v times per line, want 0", allocs)
+	}
+}
+
+func TestParseSpans(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		wantTimestamp string
+		wantLevel     string
+		wantSource    string
+	}{
+		{
+			name:          "Timestamp, level and logger",
+			input:         "2024-09-30 10:30:45,123 ERROR [main] com.example.App - Connection failed",
+			wantTimestamp: "2024-09-30 10:30:45,123",
+			wantLevel:     "ERROR",
+			wantSource:    "com.example.App",
+		},
+		{
+			name:          "Bracketed level and source",
+			input:         "2024-09-30T10:30:45Z [WARN] [db-pool] Pool almost exhausted",
+			wantTimestamp: "2024-09-30T10:30:45Z",
+			wantLevel:     "WARN",
+			wantSource:    "[db-pool]",
+		},
+		{
+			name:          "Bracketed text with spaces is not a source",
+			input:         "[2024-09-30 10:30:45] [ERROR] [2024-09-30 10:30:46] Database error",
+			wantTimestamp: "2024-09-30 10:30:45",
+			wantLevel:     "ERROR",
+		},
+		{
+			name:  "Nothing found",
+			input: "plain text",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := ParseLogLine(tt.input)
+
+			for _, check := range []struct {
+				part string
+				span Span
+				want string
+			}{
+				{"timestamp", entry.TimestampSpan, tt.wantTimestamp},
+				{"level", entry.LevelSpan, tt.wantLevel},
+				{"source", entry.SourceSpan, tt.wantSource},
+			} {
+				if got := tt.input[check.span.Start:check.span.End]; got != check.want {
+					t.Errorf("ParseLogLine() %s span covers %q, want %q", check.part, got, check.want)
+				}
+			}
+
+			if want := strings.Trim(tt.wantSource, "[]"); entry.Source != want {
+				t.Errorf("ParseLogLine() source = %q, want %q", entry.Source, tt.wantSource)
+			}
+		})
+	}
+}
+
 // Benchmark tests
 func BenchmarkParseLogLine(b *testing.B) {
 	testLine := "2024-09-30T10:30:45.123Z INFO Application started successfully"
 
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		ParseLogLine(testLine)
@@ -309,8 +517,95 @@ func BenchmarkParseLogLine(b *testing.B) {
 func BenchmarkParseLogLineComplex(b *testing.B) {
 	testLine := "[ERROR] 2024-09-30T10:30:45.123456789Z [main] com.example.Service - Database connection pool exhausted after 30 seconds"
 
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		ParseLogLine(testLine)
 	}
 }
+
+// BenchmarkParserStream parses the lines of one stream, where the cached
+// timestamp layout is reused
+func BenchmarkParserStream(b *testing.B) {
+	lines := []string{
+		"Sep 30 10:30:45 web-1 nginx[812]: INFO upstream response in 12ms",
+		"Sep 30 10:30:46 web-1 nginx[812]: WARNING slow upstream response in 950ms",
+		"Sep 30 10:30:47 web-1 nginx[812]: ERROR upstream timed out after 30s",
+		"    continuation line without timestamp nor level",
+	}
+	p := NewParser()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.Parse(lines[i%len(lines)])
+	}
+}
+
+func BenchmarkParseLogLineNoMatch(b *testing.B) {
+	testLine := "GET /api/v1/users?page=2 returned 200 to 192.168.1.10 after a very long wait on the database"
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ParseLogLine(testLine)
+	}
+}
+
+func TestSetNaiveZone(t *testing.T) {
+	defer SetNaiveZone(time.UTC)
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	SetNaiveZone(paris)
+
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"2024-09-30 10:30:45", time.Date(2024, 9, 30, 8, 30, 45, 0, time.UTC)},
+		{"2024/01/15 10:30:45", time.Date(2024, 1, 15, 9, 30, 45, 0, time.UTC)},
+		// Explicit zones win
+		{"2024-09-30T10:30:45Z", time.Date(2024, 9, 30, 10, 30, 45, 0, time.UTC)},
+		{"2024-09-30T10:30:45+01:00", time.Date(2024, 9, 30, 9, 30, 45, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseTimestamp(tt.input)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseTimestamp(%q) = %v, %v, want %v", tt.input, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseEpochLines(t *testing.T) {
+	want := time.Date(2024, 9, 30, 10, 30, 45, 123000000, time.UTC)
+	tests := []struct {
+		input    string
+		wantTime bool
+	}{
+		{"1727692245.123 INFO started", true},
+		{"1727692245123 INFO started", true},
+		{`{"ts":1727692245.123,"level":"info","msg":"started"}`, true},
+		{`{"ts":"1727692245123","level":"info","msg":"started"}`, true},
+		{"ts=1727692245123 level=info msg=started", true},
+		// Only lines starting with an epoch have one
+		{"INFO user 1727692245 logged in", false},
+		{"172769224512 INFO twelve digits", false},
+	}
+
+	p := NewParser()
+	for _, tt := range tests {
+		entry := p.Parse(tt.input)
+		if tt.wantTime && !entry.Timestamp.Equal(want) {
+			t.Errorf("Parse(%q) timestamp = %v, want %v", tt.input, entry.Timestamp, want)
+		}
+		if !tt.wantTime && !entry.Timestamp.IsZero() {
+			t.Errorf("Parse(%q) timestamp = %v, want none", tt.input, entry.Timestamp)
+		}
+		if entry.Level != LevelInfo {
+			t.Errorf("Parse(%q) level = %v, want INFO", tt.input, entry.Level)
+		}
+	}
+}
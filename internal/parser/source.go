@@ -0,0 +1,215 @@
+package parser
+
+import "strings"
+
+// findSource fills the source of a text entry from the layouts that name a
+// component: a thread or logger after the level, as in "ERROR [main]
+// com.foo.Bar - ...", logback's "[main] ERROR com.foo.Bar - ...", "INFO
+// my_app::db] ..." or Python's "ERROR:name:...", or a program in the header,
+// as in syslog's "host app[1234]: ...". A logger that becomes the source is
+// left out of the message. A
+// caller=file:line pair gives the file and line fields, and the source when
+// none was found. level is the span of the level, empty when there is none;
+// the header starts at from.
+func findSource(entry *LogEntry, line string, level Span, from int, syslog bool) {
+	if !level.IsEmpty() {
+		entry.Source, entry.SourceSpan = sourceAfter(entry, line, level.Start, level.End)
+	}
+	if entry.SourceSpan.IsEmpty() {
+		end := len(line)
+		if !level.IsEmpty() {
+			end = level.Start
+		}
+		entry.Source, entry.SourceSpan = sourceBefore(line, from, end, syslog)
+	}
+
+	if i := strings.Index(line, "caller="); i >= 0 && (i == 0 || isSpace(line[i-1])) {
+		start := i + len("caller=")
+		end := start
+		for end < len(line) && !isSpace(line[end]) {
+			end++
+		}
+		if file, lineNum, ok := splitCaller(line[start:end]); ok {
+			entry.Fields = append(entry.Fields, Field{"file", file}, Field{"line", lineNum})
+			if entry.SourceSpan.IsEmpty() {
+				entry.Source, entry.SourceSpan = file, Span{start, start + len(file)}
+			}
+		}
+	}
+}
+
+// sourceAfter returns the source following the level at line[start:end],
+// and its span. A bracketed thread followed by a dotted logger name gives
+// the logger, the thread going to the fields, as does a logger between the
+// level and a dash after a bracketed thread; a bracketed name alone is
+// returned with its brackets in its span.
+func sourceAfter(entry *LogEntry, line string, start, end int) (string, Span) {
+	i := end
+	if start > 0 && line[start-1] == '[' && i < len(line) && line[i] == ']' {
+		// Bracketed level, as in [ERROR]
+		i++
+	}
+
+	// Python's LEVEL:name:message
+	if expect(line, i, ':') {
+		if nameEnd := dottedNameEnd(line, i+1); nameEnd > i+1 && expect(line, nameEnd, ':') {
+			entry.Message = messageAfter(line, nameEnd)
+			return line[i+1 : nameEnd], Span{i + 1, nameEnd}
+		}
+		return "", Span{}
+	}
+
+	for i < len(line) && line[i] == ' ' {
+		i++
+	}
+	if i == end || i >= len(line) {
+		return "", Span{}
+	}
+
+	if line[i] != '[' {
+		// Logback's [thread] LEVEL com.foo.Bar - message
+		if nameEnd := dottedNameEnd(line, i); strings.Contains(line[i:nameEnd], ".") && strings.HasPrefix(line[nameEnd:], " - ") {
+			if thread, ok := threadBefore(line, start); ok {
+				entry.Fields = append(entry.Fields, Field{"thread", thread})
+				entry.Message = messageAfter(line, nameEnd)
+				return line[i:nameEnd], Span{i, nameEnd}
+			}
+		}
+
+		// Rust's module::path, closing the bracket of the header or
+		// followed by a colon
+		nameEnd := i
+		for nameEnd < len(line) && (isWordByte(line[nameEnd]) || line[nameEnd] == ':') {
+			nameEnd++
+		}
+		name := strings.TrimRight(line[i:nameEnd], ":")
+		if strings.Contains(name, "::") && isWordByte(name[0]) {
+			return name, Span{i, i + len(name)}
+		}
+		return "", Span{}
+	}
+
+	for j := i + 1; j < len(line); j++ {
+		switch line[j] {
+		case ']':
+			if j == i+1 {
+				return "", Span{}
+			}
+			thread := line[i+1 : j]
+
+			// A Java logger after the thread, as in [main] com.foo.Bar - message
+			k := j + 1
+			if expect(line, k, ' ') {
+				if nameEnd := dottedNameEnd(line, k+1); nameEnd > k+1 && strings.Contains(line[k+1:nameEnd], ".") &&
+					(nameEnd == len(line) || line[nameEnd] == ' ' || line[nameEnd] == ':') {
+					entry.Fields = append(entry.Fields, Field{"thread", thread})
+					entry.Message = messageAfter(line, nameEnd)
+					return line[k+1 : nameEnd], Span{k + 1, nameEnd}
+				}
+			}
+			return thread, Span{i, j + 1}
+		case ' ', '\t':
+			return "", Span{}
+		}
+	}
+	return "", Span{}
+}
+
+// threadBefore returns the bracketed thread right before the level starting
+// at line[start], as in "[main] ERROR"
+func threadBefore(line string, start int) (string, bool) {
+	if start < 3 || line[start-1] != ' ' || line[start-2] != ']' {
+		return "", false
+	}
+	for i := start - 3; i >= 0 && !isSpace(line[i]); i-- {
+		if line[i] == '[' {
+			return line[i+1 : start-2], i+1 < start-2
+		}
+	}
+	return "", false
+}
+
+// messageAfter returns the message following the logger name ending at
+// line[end], past the colon or dash separating them
+func messageAfter(line string, end int) string {
+	rest := strings.TrimLeft(line[end:], " ")
+	if strings.HasPrefix(rest, ":") || strings.HasPrefix(rest, "- ") {
+		rest = rest[1:]
+	}
+	return strings.TrimSpace(rest)
+}
+
+// sourceBefore returns the program named in the header line[from:end], as
+// in "host app[1234]:" or, after a syslog timestamp, "host kernel:", or the
+// logger named before the level in "name - LEVEL - message"
+func sourceBefore(line string, from, end int, syslog bool) (string, Span) {
+	if end < len(line) && end >= 3 && line[end-3:end] == " - " {
+		nameStart := end - 3
+		for nameStart > from && !isSpace(line[nameStart-1]) {
+			nameStart--
+		}
+		if dottedNameEnd(line, nameStart) == end-3 && nameStart < end-3 {
+			return line[nameStart : end-3], Span{nameStart, end - 3}
+		}
+	}
+
+	// The first tokens of the header
+	tokens := 0
+	for i := from; i < end && tokens < 3; {
+		for i < end && isSpace(line[i]) {
+			i++
+		}
+		start := i
+		for i < end && !isSpace(line[i]) {
+			i++
+		}
+		if start == i {
+			break
+		}
+		tokens++
+
+		token := line[start:i]
+		if !strings.HasSuffix(token, ":") {
+			continue
+		}
+		name := token[:len(token)-1]
+		if open := strings.IndexByte(name, '['); open > 0 && strings.HasSuffix(name, "]") && isNumber(name[open+1:len(name)-1]) {
+			return name[:open], Span{start, start + open}
+		}
+		if syslog && tokens == 2 && dottedNameEnd(name, 0) == len(name) && name != "" {
+			return name, Span{start, start + len(name)}
+		}
+	}
+	return "", Span{}
+}
+
+// splitCaller splits a caller such as pkg/foo.go:42 into its file and line
+func splitCaller(caller string) (string, string, bool) {
+	colon := strings.LastIndexByte(caller, ':')
+	if colon <= 0 || !isNumber(caller[colon+1:]) {
+		return "", "", false
+	}
+	return caller[:colon], caller[colon+1:], true
+}
+
+// dottedNameEnd returns the end of the name starting at line[i], made of
+// word characters, dots and dashes, as logger names
+func dottedNameEnd(line string, i int) int {
+	for i < len(line) && (isWordByte(line[i]) || line[i] == '.' || line[i] == '-') {
+		i++
+	}
+	return i
+}
+
+// isNumber reports whether s is made of digits only
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
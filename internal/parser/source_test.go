@@ -0,0 +1,117 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindSource(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantSource string
+		wantSpan   string
+		wantFields []Field
+		// wantMessage is checked when set
+		wantMessage string
+	}{
+		{
+			name:       "Bracketed thread",
+			input:      "2024-09-30 10:30:45 INFO [worker-3] job done",
+			wantSource: "worker-3",
+			wantSpan:   "[worker-3]",
+		},
+		{
+			name:        "Java thread and logger",
+			input:       "2024-09-30 10:30:45 WARN [main] com.foo.Bar - slow query",
+			wantSource:  "com.foo.Bar",
+			wantSpan:    "com.foo.Bar",
+			wantFields:  []Field{{"thread", "main"}},
+			wantMessage: "slow query",
+		},
+		{
+			name:        "Logback thread before the level",
+			input:       "2024-09-30 10:30:45 [http-nio-8080-exec-1] INFO c.f.Controller - request handled",
+			wantSource:  "c.f.Controller",
+			wantSpan:    "c.f.Controller",
+			wantFields:  []Field{{"thread", "http-nio-8080-exec-1"}},
+			wantMessage: "request handled",
+		},
+		{
+			name:  "Dotted word after the level without a thread",
+			input: "2024-09-30 10:30:45 INFO v1.2 - released",
+		},
+		{
+			name:       "Syslog program with pid",
+			input:      "Sep 30 10:30:45 web-1 nginx[812]: ERROR upstream timed out",
+			wantSource: "nginx",
+			wantSpan:   "nginx",
+		},
+		{
+			name:       "Syslog program without pid",
+			input:      "Sep 30 10:30:45 web-1 kernel: eth0 link up",
+			wantSource: "kernel",
+			wantSpan:   "kernel",
+		},
+		{
+			name:       "Rust module path",
+			input:      "[2024-09-30T10:30:45Z INFO  my_app::db::pool] connection opened",
+			wantSource: "my_app::db::pool",
+			wantSpan:   "my_app::db::pool",
+		},
+		{
+			name:        "Python default format",
+			input:       "ERROR:myapp.db:connection lost",
+			wantSource:  "myapp.db",
+			wantSpan:    "myapp.db",
+			wantMessage: "connection lost",
+		},
+		{
+			name:       "Python name before the level",
+			input:      "2024-09-30 10:30:45,123 - myapp.api - WARNING - slow request",
+			wantSource: "myapp.api",
+			wantSpan:   "myapp.api",
+		},
+		{
+			name:       "Caller",
+			input:      "2024-09-30 10:30:45 ERROR request failed caller=server/http.go:42",
+			wantSource: "server/http.go",
+			wantSpan:   "server/http.go",
+			wantFields: []Field{{"file", "server/http.go"}, {"line", "42"}},
+		},
+		{
+			name:       "Caller and thread",
+			input:      "2024-09-30 10:30:45 ERROR [db] failed caller=db.go:7",
+			wantSource: "db",
+			wantSpan:   "[db]",
+			wantFields: []Field{{"file", "db.go"}, {"line", "7"}},
+		},
+		{
+			name:  "Message colon is not a source",
+			input: "2024-09-30 10:30:45 INFO user bob: logged in",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := ParseLogLine(tt.input)
+			if entry.Source != tt.wantSource {
+				t.Errorf("Source = %q, want %q", entry.Source, tt.wantSource)
+			}
+			if got := tt.input[entry.SourceSpan.Start:entry.SourceSpan.End]; got != tt.wantSpan {
+				t.Errorf("source span covers %q, want %q", got, tt.wantSpan)
+			}
+			if !reflect.DeepEqual(entry.Fields, tt.wantFields) {
+				t.Errorf("Fields = %q, want %q", entry.Fields, tt.wantFields)
+			}
+			if tt.wantMessage != "" && entry.Message != tt.wantMessage {
+				t.Errorf("Message = %q, want %q", entry.Message, tt.wantMessage)
+			}
+		})
+	}
+
+	entry := ParseLogLine(`{"level":"info","msg":"ok","caller":"main.go:12"}`)
+	if want := []Field{{"file", "main.go"}, {"line", "12"}}; !reflect.DeepEqual(entry.Fields, want) {
+		t.Errorf("JSON caller fields = %q, want %q", entry.Fields, want)
+	}
+}
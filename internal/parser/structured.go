@@ -0,0 +1,345 @@
+package parser
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Format is the format a line was parsed as
+type Format uint8
+
+const (
+	// FormatText is a free-form line
+	FormatText Format = iota
+	// FormatJSON is a JSON object, one per line
+	FormatJSON
+	// FormatLogfmt is a line of key=value pairs, as in level=info msg="started"
+	FormatLogfmt
+	// FormatJournal is a journald entry, as printed by journalctl -o json
+	FormatJournal
+	// FormatGELF is a Graylog Extended Log Format message
+	FormatGELF
+	// FormatCEF is an ArcSight Common Event Format event
+	FormatCEF
+	// FormatLEEF is a QRadar Log Event Extended Format event
+	FormatLEEF
+)
+
+// String returns the name of a format
+func (f Format) String() string {
+	switch f {
+	case FormatJSON:
+		return "json"
+	case FormatLogfmt:
+		return "logfmt"
+	case FormatJournal:
+		return "journal"
+	case FormatGELF:
+		return "gelf"
+	case FormatCEF:
+		return "cef"
+	case FormatLEEF:
+		return "leef"
+	default:
+		return "text"
+	}
+}
+
+// Field is a key and value of a structured entry. String values are
+// unquoted; other JSON values are kept as JSON text.
+type Field struct {
+	Key   string
+	Value string
+}
+
+// fieldRole is the part of an entry a structured field holds
+type fieldRole uint8
+
+const (
+	roleNone fieldRole = iota
+	roleTime
+	roleLevel
+	roleMessage
+	roleSource
+	roleCaller
+)
+
+// roleOf returns the part of an entry held by the fields named key
+func roleOf(key string) fieldRole {
+	switch key {
+	case "time", "ts", "timestamp", "@timestamp", "datetime", "date":
+		return roleTime
+	case "level", "lvl", "severity", "loglevel":
+		return roleLevel
+	case "msg", "message", "@message":
+		return roleMessage
+	case "logger", "source", "component", "module":
+		return roleSource
+	case "caller":
+		return roleCaller
+	}
+	return roleNone
+}
+
+// jsonDialect is the way the JSON objects of a producer name their fields
+type jsonDialect struct {
+	format Format
+	role   func(key string) fieldRole
+	// finish completes the entry once its fields are set
+	finish func(entry *LogEntry)
+}
+
+var plainJSON = jsonDialect{format: FormatJSON, role: roleOf}
+
+// dialectOf tells the producer of a JSON object from its well-known fields
+func dialectOf(object string) jsonDialect {
+	switch {
+	case isJournal(object):
+		return journalDialect
+	case isGELF(object):
+		return gelfDialect
+	}
+	return plainJSON
+}
+
+// parseStructured parses a JSON, CEF, LEEF or logfmt line. Well-known fields fill the
+// timestamp, level, message and source of the entry, whose spans then
+// locate their values in the line; the others are kept in Fields, in order.
+func parseStructured(line string) (LogEntry, bool) {
+	i := 0
+	for i < len(line) && isSpace(line[i]) {
+		i++
+	}
+	if i == len(line) {
+		return LogEntry{}, false
+	}
+
+	if line[i] == '{' {
+		return parseJSON(line, i)
+	}
+	if entry, ok := parseSecurityEvent(line); ok {
+		return entry, true
+	}
+	if isKeyByte(line[i]) {
+		return parseLogfmt(line, i)
+	}
+	return LogEntry{}, false
+}
+
+// setField fills the part of entry held by a field in the given role, or
+// appends the field to its Fields. The value is at line[start:end], quotes
+// excluded. It reports whether the field gave the entry its timestamp, level
+// or message.
+func setField(entry *LogEntry, role fieldRole, key, value string, start, end int, quoted bool) bool {
+	span := Span{start, end}
+	switch role {
+	case roleTime:
+		if entry.TimestampSpan.IsEmpty() {
+			if t, err := parseTimestamp(value); err == nil {
+				entry.Timestamp, entry.TimestampSpan = t, span
+				return true
+			}
+		}
+	case roleLevel:
+		if entry.LevelSpan.IsEmpty() {
+			if level, ok := lookupLevelValue(value); ok {
+				entry.Level, entry.LevelSpan, entry.LevelConfidence = level, span, markedScore
+				return true
+			}
+		}
+	case roleMessage:
+		if entry.Message == "" && quoted {
+			entry.Message = value
+			return true
+		}
+	case roleSource:
+		if entry.SourceSpan.IsEmpty() && quoted && value != "" {
+			entry.Source, entry.SourceSpan = value, span
+			return false
+		}
+	case roleCaller:
+		// Split as in text lines, into file and line fields
+		if file, lineNum, ok := splitCaller(value); ok && quoted {
+			entry.Fields = append(entry.Fields, Field{"file", file}, Field{"line", lineNum})
+			return false
+		}
+	}
+
+	entry.Fields = append(entry.Fields, Field{Key: key, Value: value})
+	return false
+}
+
+// parseJSON parses the JSON object opening at line[start]. The objects of
+// well-known producers, such as journald or GELF, name their fields their
+// own way.
+func parseJSON(line string, start int) (LogEntry, bool) {
+	end := closingBracket(line, start)
+	if end < 0 || !isBlank(line[end:]) || !json.Valid([]byte(line[start:end])) {
+		return LogEntry{}, false
+	}
+
+	dialect := dialectOf(line[start:end])
+	format, roles := dialect.format, dialect.role
+
+	entry := LogEntry{Raw: line, Level: LevelUnknown, Format: format}
+	i := skipSpaces(line, start+1)
+	for i < end && line[i] == '"' {
+		keyEnd := stringEnd(line, i)
+		key := unquoteJSON(line[i:keyEnd])
+
+		// The object is valid: a colon follows the key, then the value
+		i = skipSpaces(line, skipSpaces(line, keyEnd)+1)
+		valueEnd := jsonValueEnd(line, i)
+		value := line[i:valueEnd]
+		switch {
+		case value[0] == '"':
+			setField(&entry, roles(key), key, unquoteJSON(value), i+1, valueEnd-1, true)
+		case format == FormatJournal && value[0] == '[':
+			// Binary values are arrays of bytes
+			if text, ok := journalBytes(value); ok {
+				setField(&entry, roles(key), key, text, i, valueEnd, true)
+				break
+			}
+			fallthrough
+		default:
+			setField(&entry, roles(key), key, value, i, valueEnd, false)
+		}
+
+		i = skipSpaces(line, valueEnd)
+		if line[i] == ',' {
+			i = skipSpaces(line, i+1)
+		}
+	}
+	if dialect.finish != nil {
+		dialect.finish(&entry)
+	}
+	return entry, true
+}
+
+// parseLogfmt parses the key=value pairs starting at line[start]. The line
+// must hold nothing else, with at least two pairs of which one gives the
+// entry its timestamp, level or message, so that text lines holding a few
+// pairs are left alone.
+func parseLogfmt(line string, start int) (LogEntry, bool) {
+	// Most lines start with no key at all: check the first one before
+	// allocating anything
+	if i := keyEnd(line, start); i == start || i >= len(line) || line[i] != '=' {
+		return LogEntry{}, false
+	}
+
+	entry := LogEntry{Raw: line, Level: LevelUnknown, Format: FormatLogfmt}
+	pairs, known := 0, false
+	for i := start; i < len(line); {
+		end := keyEnd(line, i)
+		if end == i || end >= len(line) || line[end] != '=' {
+			return LogEntry{}, false
+		}
+		key := line[i:end]
+
+		// Unquoted logfmt values are strings too
+		i = end + 1
+		if i < len(line) && line[i] == '"' {
+			end = stringEnd(line, i)
+			if end > len(line) {
+				return LogEntry{}, false
+			}
+			known = setField(&entry, roleOf(key), key, unquoteLogfmt(line[i:end]), i+1, end-1, true) || known
+		} else {
+			end = i
+			for end < len(line) && !isSpace(line[end]) {
+				end++
+			}
+			known = setField(&entry, roleOf(key), key, line[i:end], i, end, true) || known
+		}
+		if i = end; i < len(line) && !isSpace(line[i]) {
+			return LogEntry{}, false
+		}
+		pairs++
+		i = skipSpaces(line, i)
+	}
+
+	if pairs < 2 || !known {
+		return LogEntry{}, false
+	}
+	return entry, true
+}
+
+// keyEnd returns the end of the logfmt key starting at line[i]
+func keyEnd(line string, i int) int {
+	for i < len(line) && isKeyByte(line[i]) {
+		i++
+	}
+	return i
+}
+
+// isKeyByte reports whether c may be part of a logfmt key
+func isKeyByte(c byte) bool {
+	return isWordByte(c) || c == '.' || c == '-' || c == '/' || c == '@'
+}
+
+// stringEnd returns the end of the double-quoted string opening at line[i],
+// or len(line)+1 when it is not closed
+func stringEnd(line string, i int) int {
+	for i++; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(line) + 1
+}
+
+// jsonValueEnd returns the end of the valid JSON value starting at line[i]
+func jsonValueEnd(line string, i int) int {
+	switch line[i] {
+	case '"':
+		return stringEnd(line, i)
+	case '{', '[':
+		return closingBracket(line, i)
+	}
+	end := i
+	for end < len(line) && !isSpace(line[end]) && line[end] != ',' && line[end] != '}' && line[end] != ']' {
+		end++
+	}
+	return end
+}
+
+// unquoteJSON returns the text of a valid JSON string
+func unquoteJSON(quoted string) string {
+	text := quoted[1 : len(quoted)-1]
+	if strings.IndexByte(text, '\\') < 0 {
+		return text
+	}
+	json.Unmarshal([]byte(quoted), &text)
+	return text
+}
+
+// unquoteLogfmt returns the text of a quoted logfmt value, which uses the
+// escapes of Go strings
+func unquoteLogfmt(quoted string) string {
+	if text, err := strconv.Unquote(quoted); err == nil {
+		return text
+	}
+	return quoted[1 : len(quoted)-1]
+}
+
+// skipSpaces returns the index of the first non-space byte from line[i]
+func skipSpaces(line string, i int) int {
+	for i < len(line) && isSpace(line[i]) {
+		i++
+	}
+	return i
+}
+
+// isBlank reports whether s holds only spaces
+func isBlank(s string) bool {
+	return skipSpaces(s, 0) == len(s)
+}
+
+// isSpace reports whether c is a space as matched by \s
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\f' || c == '\r'
+}
@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseStructured(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantFormat Format
+		wantTime   string
+		wantLevel  LogLevel
+		wantMsg    string
+		wantSource string
+		wantFields []Field
+	}{
+		{
+			name:       "JSON entry",
+			input:      `{"time":"2024-09-30T10:30:45.123Z","level":"error","logger":"db","msg":"query failed","query_ms":1200,"tags":["a","b"],"err":"timeout: \"x\""}`,
+			wantFormat: FormatJSON,
+			wantTime:   "10:30:45.123",
+			wantLevel:  LevelError,
+			wantMsg:    "query failed",
+			wantSource: "db",
+			wantFields: []Field{{"query_ms", "1200"}, {"tags", `["a","b"]`}, {"err", `timeout: "x"`}},
+		},
+		{
+			name:       "JSON with spaces and numeric level",
+			input:      ` { "message" : "hello" , "level" : 30 , "nested": {"a": 1} } `,
+			wantFormat: FormatJSON,
+			wantLevel:  LevelInfo,
+			wantMsg:    "hello",
+			wantFields: []Field{{"nested", `{"a": 1}`}},
+		},
+		{
+			name:       "JSON with unknown level",
+			input:      `{"msg":"hello","level":"loud"}`,
+			wantFormat: FormatJSON,
+			wantLevel:  LevelUnknown,
+			wantMsg:    "hello",
+			wantFields: []Field{{"level", "loud"}},
+		},
+		{
+			name:       "Logfmt entry",
+			input:      `ts=2024-09-30T10:30:45Z level=warn component=api msg="slow request" path=/users duration=1.2s`,
+			wantFormat: FormatLogfmt,
+			wantTime:   "10:30:45.000",
+			wantLevel:  LevelWarn,
+			wantMsg:    "slow request",
+			wantSource: "api",
+			wantFields: []Field{{"path", "/users"}, {"duration", "1.2s"}},
+		},
+		{
+			name:       "Pairs without known keys are text",
+			input:      "user=bob action=login",
+			wantFormat: FormatText,
+			wantLevel:  LevelUnknown,
+			wantMsg:    "user=bob action=login",
+		},
+		{
+			name:       "Text with trailing pairs",
+			input:      "2024-09-30 10:30:45 INFO served user=bob",
+			wantFormat: FormatText,
+			wantTime:   "10:30:45.000",
+			wantLevel:  LevelInfo,
+			wantMsg:    "served user=bob",
+		},
+		{
+			name:       "Invalid JSON is text",
+			input:      `{"level":"error", broken`,
+			wantFormat: FormatText,
+			wantLevel:  LevelError,
+			wantMsg:    `", broken`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := ParseLogLine(tt.input)
+			got := ""
+			if !entry.Timestamp.IsZero() {
+				got = entry.Timestamp.Format("15:04:05.000")
+			}
+
+			if entry.Format != tt.wantFormat {
+				t.Errorf("Format = %v, want %v", entry.Format, tt.wantFormat)
+			}
+			if got != tt.wantTime {
+				t.Errorf("Timestamp = %q, want %q", got, tt.wantTime)
+			}
+			if entry.Level != tt.wantLevel {
+				t.Errorf("Level = %v, want %v", entry.Level, tt.wantLevel)
+			}
+			if entry.Message != tt.wantMsg {
+				t.Errorf("Message = %q, want %q", entry.Message, tt.wantMsg)
+			}
+			if entry.Source != tt.wantSource {
+				t.Errorf("Source = %q, want %q", entry.Source, tt.wantSource)
+			}
+			if !reflect.DeepEqual(entry.Fields, tt.wantFields) {
+				t.Errorf("Fields = %q, want %q", entry.Fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestParseStructuredSpans(t *testing.T) {
+	line := `level=info ts="2024-09-30 10:30:45" logger=api msg=ok`
+	entry := ParseLogLine(line)
+
+	for name, tt := range map[string]struct {
+		span Span
+		want string
+	}{
+		"timestamp": {entry.TimestampSpan, "2024-09-30 10:30:45"},
+		"level":     {entry.LevelSpan, "info"},
+		"source":    {entry.SourceSpan, "api"},
+	} {
+		if got := line[tt.span.Start:tt.span.End]; got != tt.want {
+			t.Errorf("%s span covers %q, want %q", name, got, tt.want)
+		}
+	}
+	if want := time.Date(2024, 9, 30, 10, 30, 45, 0, time.UTC); !entry.Timestamp.Equal(want) {
+		t.Errorf("Timestamp = %v, want %v", entry.Timestamp, want)
+	}
+}
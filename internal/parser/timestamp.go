@@ -0,0 +1,380 @@
+package parser
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// timestampShape is one of the timestamp layouts recognized by the scanner
+type timestampShape uint8
+
+const (
+	shapeNone timestampShape = iota
+	// shapeISO is 2006-01-02T15:04:05, with a T or a space, an optional
+	// fraction and an optional Z or ±07:00 zone
+	shapeISO
+	// shapeSlashYMD is 2006/01/02 15:04:05
+	shapeSlashYMD
+	// shapeSlashMDY is 01/02/2006 15:04:05
+	shapeSlashMDY
+	// shapeSyslog is Jan 02 15:04:05, with a zero or space padded day and no year
+	shapeSyslog
+	// shapeApache is 02/Jan/2006:15:04:05 -0700, as in access logs
+	shapeApache
+	// shapeEpoch is a Unix time in seconds, milliseconds, microseconds or
+	// nanoseconds, only recognized at the start of lines
+	shapeEpoch
+)
+
+// monthNames are the abbreviations accepted by shapeSyslog and shapeApache
+var monthNames = [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"}
+
+var (
+	// naiveZone is the zone of timestamps written without one
+	naiveZone = time.UTC
+
+	// now is the time syslog timestamps, which have no year, are placed before
+	now = time.Now
+)
+
+// SetNaiveZone sets the zone of timestamps written without one, UTC by
+// default. It must not be called while lines are being parsed.
+func SetNaiveZone(zone *time.Location) {
+	naiveZone = zone
+}
+
+// NaiveZone returns the zone of timestamps written without one
+func NaiveZone() *time.Location {
+	return naiveZone
+}
+
+// zones caches the fixed zones of numeric offsets, so that parsing one does
+// not allocate every time
+var zones sync.Map
+
+// fixedZone returns a zone named like those of time.Parse for an offset
+func fixedZone(offset int) *time.Location {
+	if zone, ok := zones.Load(offset); ok {
+		return zone.(*time.Location)
+	}
+	zone, _ := zones.LoadOrStore(offset, time.FixedZone("", offset))
+	return zone.(*time.Location)
+}
+
+// scanTimestamp reads a timestamp of any shape starting at line[i]. It
+// returns the shape found and the end of the timestamp.
+func scanTimestamp(line string, i int) (time.Time, timestampShape, int, bool) {
+	// Every shape has a separator at its third or fifth byte: check it
+	// first, as most words start no timestamp
+	c := line[i]
+	if !expect(line, i+2, '/') && !expect(line, i+3, ' ') && !expect(line, i+4, '-') && !expect(line, i+4, '/') {
+		return time.Time{}, shapeNone, 0, false
+	}
+
+	if isDigit(c) {
+		for _, shape := range []timestampShape{shapeISO, shapeSlashYMD, shapeSlashMDY, shapeApache} {
+			if t, end, ok := scanShape(line, i, shape); ok {
+				return t, shape, end, true
+			}
+		}
+	} else if c >= 'A' && c <= 'z' {
+		if t, end, ok := scanShape(line, i, shapeSyslog); ok {
+			return t, shapeSyslog, end, true
+		}
+	}
+	return time.Time{}, shapeNone, 0, false
+}
+
+// scanShape reads a timestamp of the given shape starting at line[i]
+func scanShape(line string, i int, shape timestampShape) (time.Time, int, bool) {
+	var year, month, day int
+	ok := true
+
+	switch shape {
+	case shapeEpoch:
+		return scanEpoch(line, i)
+	case shapeISO:
+		year, i, ok = digits(line, i, 4)
+		ok = ok && expect(line, i, '-')
+		month, i, ok = digitsAfter(ok, line, i+1, 2)
+		ok = ok && expect(line, i, '-')
+		day, i, ok = digitsAfter(ok, line, i+1, 2)
+		ok = ok && (expect(line, i, 'T') || expect(line, i, ' '))
+		i++
+	case shapeSlashYMD:
+		year, i, ok = digits(line, i, 4)
+		ok = ok && expect(line, i, '/')
+		month, i, ok = digitsAfter(ok, line, i+1, 2)
+		ok = ok && expect(line, i, '/')
+		day, i, ok = digitsAfter(ok, line, i+1, 2)
+		ok = ok && expect(line, i, ' ')
+		i++
+	case shapeSlashMDY:
+		month, i, ok = digits(line, i, 2)
+		ok = ok && expect(line, i, '/')
+		day, i, ok = digitsAfter(ok, line, i+1, 2)
+		ok = ok && expect(line, i, '/')
+		year, i, ok = digitsAfter(ok, line, i+1, 4)
+		ok = ok && expect(line, i, ' ')
+		i++
+	case shapeSyslog:
+		if month, ok = monthAt(line, i); !ok || !expect(line, i+3, ' ') {
+			return time.Time{}, 0, false
+		}
+		i += 4
+		if expect(line, i, ' ') {
+			// Space padded day
+			i++
+			day, i, ok = digits(line, i, 1)
+		} else {
+			day, i, ok = digits(line, i, 2)
+		}
+		ok = ok && expect(line, i, ' ')
+		i++
+	case shapeApache:
+		day, i, ok = digits(line, i, 2)
+		ok = ok && expect(line, i, '/')
+		if ok {
+			month, ok = monthAt(line, i+1)
+		}
+		ok = ok && expect(line, i+4, '/')
+		year, i, ok = digitsAfter(ok, line, i+5, 4)
+		ok = ok && expect(line, i, ':')
+		i++
+	default:
+		return time.Time{}, 0, false
+	}
+	if !ok {
+		return time.Time{}, 0, false
+	}
+
+	hour, i, ok := digits(line, i, 2)
+	ok = ok && expect(line, i, ':')
+	minute, i, ok := digitsAfter(ok, line, i+1, 2)
+	ok = ok && expect(line, i, ':')
+	second, i, ok := digitsAfter(ok, line, i+1, 2)
+	if !ok {
+		return time.Time{}, 0, false
+	}
+
+	// Fractions of any precision, after a dot or the comma of log4j
+	nanos := 0
+	if (expect(line, i, '.') || expect(line, i, ',')) && i+1 < len(line) && isDigit(line[i+1]) {
+		i++
+		nanos, i = fraction(line, i)
+	}
+
+	zone := naiveZone
+	switch {
+	case shape == shapeISO && expect(line, i, 'Z'):
+		zone = time.UTC
+		i++
+	case shape == shapeISO:
+		if offsetZone, end, ok := zoneAt(line, i); ok {
+			zone, i = offsetZone, end
+		}
+	case shape == shapeApache:
+		offsetZone, end, ok := zoneAt(line, i+1)
+		if !expect(line, i, ' ') || !ok {
+			return time.Time{}, 0, false
+		}
+		zone, i = offsetZone, end
+	}
+
+	if month < 1 || month > 12 || day < 1 || day > daysIn(month, year) ||
+		hour > 23 || minute > 59 || second > 59 {
+		return time.Time{}, 0, false
+	}
+
+	if shape == shapeSyslog {
+		return syslogTime(month, day, hour, minute, second, zone), i, true
+	}
+	return time.Date(year, time.Month(month), day, hour, minute, second, nanos, zone), i, true
+}
+
+// syslogTime returns the last time matching a syslog timestamp that is not
+// ahead of now, with a day of slack for clocks out of sync: a log read in
+// January places its December lines in the previous year.
+func syslogTime(month, day, hour, minute, second int, zone *time.Location) time.Time {
+	current := now()
+	for year := current.Year(); ; year-- {
+		if day > daysIn(month, year) {
+			// Feb 29 of a leap year
+			continue
+		}
+		t := time.Date(year, time.Month(month), day, hour, minute, second, 0, zone)
+		if t.Sub(current) <= 24*time.Hour {
+			return t
+		}
+	}
+}
+
+// fraction reads the digits of a fraction of second at line[i], as
+// nanoseconds. Digits past the nanosecond are ignored.
+func fraction(line string, i int) (int, int) {
+	nanos, scale := 0, int(time.Second)
+	for ; i < len(line) && isDigit(line[i]); i++ {
+		if scale > 1 {
+			scale /= 10
+			nanos += int(line[i]-'0') * scale
+		}
+	}
+	return nanos, i
+}
+
+// zoneAt reads a numeric zone offset at line[i], as -07:00 or -0700
+func zoneAt(line string, i int) (*time.Location, int, bool) {
+	if i >= len(line) || (line[i] != '+' && line[i] != '-') {
+		return nil, 0, false
+	}
+	hour, end, ok := digits(line, i+1, 2)
+	if expect(line, end, ':') {
+		end++
+	}
+	minute, end, ok := digitsAfter(ok, line, end, 2)
+	if !ok || hour > 23 || minute > 59 || (end < len(line) && isDigit(line[end])) {
+		return nil, 0, false
+	}
+
+	offset := hour*3600 + minute*60
+	if line[i] == '-' {
+		offset = -offset
+	}
+	return fixedZone(offset), end, true
+}
+
+// epochUnits are the units of Unix times by number of digits: 10 digits
+// count seconds until 2286, 13 milliseconds, 16 microseconds, 19 nanoseconds
+var epochUnits = map[int]time.Duration{
+	10: time.Second,
+	13: time.Millisecond,
+	16: time.Microsecond,
+	19: time.Nanosecond,
+}
+
+// scanEpoch reads a Unix time starting at line[i], with an optional
+// fraction of its unit. Its number of digits tells its unit.
+func scanEpoch(line string, i int) (time.Time, int, bool) {
+	end := i
+	for end < len(line) && isDigit(line[end]) {
+		end++
+	}
+	unit, ok := epochUnits[end-i]
+	if !ok {
+		return time.Time{}, 0, false
+	}
+
+	t, end, ok := epochTime(line, i, end, unit)
+	if !ok || (end < len(line) && (isWordByte(line[end]) || line[end] == '.')) {
+		return time.Time{}, 0, false
+	}
+	return t, end, true
+}
+
+// parseEpoch parses a whole string holding a Unix time, as found in the
+// fields of structured entries. Numbers of 9 to 19 digits are taken, in the
+// unit that puts them closest to now.
+func parseEpoch(s string) (time.Time, bool) {
+	intDigits := 0
+	for intDigits < len(s) && isDigit(s[intDigits]) {
+		intDigits++
+	}
+	if intDigits < 9 || intDigits > 19 {
+		return time.Time{}, false
+	}
+
+	unit := time.Nanosecond
+	switch {
+	case intDigits <= 11:
+		unit = time.Second
+	case intDigits <= 14:
+		unit = time.Millisecond
+	case intDigits <= 17:
+		unit = time.Microsecond
+	}
+	t, end, ok := epochTime(s, 0, intDigits, unit)
+	return t, ok && end == len(s)
+}
+
+// epochTime reads a Unix time counted in unit, whose integer part is
+// line[i:end], followed by an optional fraction
+func epochTime(line string, i, end int, unit time.Duration) (time.Time, int, bool) {
+	var n uint64
+	for j := i; j < end; j++ {
+		n = n*10 + uint64(line[j]-'0')
+	}
+	if n > math.MaxInt64 {
+		return time.Time{}, 0, false
+	}
+
+	nanos := 0
+	if expect(line, end, '.') && end+1 < len(line) && isDigit(line[end+1]) {
+		nanos, end = fraction(line, end+1)
+	}
+
+	perSecond := int64(time.Second / unit)
+	sec, rest := int64(n)/perSecond, int64(n)%perSecond
+	return time.Unix(sec, rest*int64(unit)+int64(nanos)/perSecond).UTC(), end, true
+}
+
+// digits reads an n-digit decimal number at line[i]
+func digits(line string, i, n int) (int, int, bool) {
+	if i+n > len(line) {
+		return 0, i, false
+	}
+	value := 0
+	for end := i + n; i < end; i++ {
+		if !isDigit(line[i]) {
+			return 0, i, false
+		}
+		value = value*10 + int(line[i]-'0')
+	}
+	return value, i, true
+}
+
+// digitsAfter is digits chained after a check that may have failed
+func digitsAfter(ok bool, line string, i, n int) (int, int, bool) {
+	if !ok {
+		return 0, i, false
+	}
+	return digits(line, i, n)
+}
+
+// expect reports whether line[i] is c
+func expect(line string, i int, c byte) bool {
+	return i < len(line) && line[i] == c
+}
+
+// monthAt reads a month abbreviation, in any case, at line[i]
+func monthAt(line string, i int) (int, bool) {
+	if i+3 > len(line) {
+		return 0, false
+	}
+	for m, name := range monthNames {
+		if line[i]|0x20 == name[0]|0x20 && line[i+1]|0x20 == name[1] && line[i+2]|0x20 == name[2] {
+			return m + 1, true
+		}
+	}
+	return 0, false
+}
+
+// daysIn returns the number of days of a month, year 0 being a leap year as
+// in time.Parse
+func daysIn(month, year int) int {
+	switch month {
+	case 2:
+		if year%4 == 0 && (year%100 != 0 || year%400 == 0) {
+			return 29
+		}
+		return 28
+	case 4, 6, 9, 11:
+		return 30
+	default:
+		return 31
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}